@@ -0,0 +1,228 @@
+package urlvector
+
+import (
+	"bytes"
+	"net/netip"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+)
+
+// Max length of host to decode, see https://www.rfc-editor.org/rfc/rfc1035#section-2.3.4.
+const maxHostLen = 255
+
+var (
+	bLocalhostSuffix = []byte(".localhost")
+
+	// Special purpose ranges aren't covered by netip.Addr methods, see
+	// https://www.iana.org/assignments/iana-ipv4-special-registry and
+	// https://www.iana.org/assignments/iana-ipv6-special-registry.
+	nonPublicPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("0.0.0.0/8"),
+		netip.MustParsePrefix("100.64.0.0/10"),
+		netip.MustParsePrefix("192.0.0.0/24"),
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.18.0.0/15"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("203.0.113.0/24"),
+		netip.MustParsePrefix("240.0.0.0/4"),
+		netip.MustParsePrefix("64:ff9b:1::/48"),
+		netip.MustParsePrefix("100::/64"),
+		netip.MustParsePrefix("2001::/23"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("2002::/16"),
+	}
+)
+
+// HostAddr returns IP address of host and true if host is an IP address.
+//
+// IPv4 address accepts in all forms of WHATWG URL parser: decimal (2130706433), octal (0177.0.0.1), hexadecimal
+// (0x7f.0x1), shortened (127.1) and percent-encoded (%31%32%37.0.0.1). IPv6 address must be enclosed in square
+// brackets, zone may be percent-encoded ([fe80::1%25eth0]).
+func (vec *Vector) HostAddr() (netip.Addr, bool) {
+	return parseHostAddr(vec.HostnameBytes())
+}
+
+// HostIsIP checks if host is an IP address.
+func (vec *Vector) HostIsIP() bool {
+	_, ok := vec.HostAddr()
+	return ok
+}
+
+// HostIsLoopback checks if host is a loopback address (127.0.0.0/8, ::1) or localhost domain (localhost,
+// *.localhost), see https://www.rfc-editor.org/rfc/rfc6761#section-6.3.
+func (vec *Vector) HostIsLoopback() bool {
+	if addr, ok := vec.HostAddr(); ok {
+		return addr.Unmap().IsLoopback()
+	}
+	host := trimDot(vec.HostnameBytes())
+	return bytes.EqualFold(host, bLocalhost) ||
+		len(host) > len(bLocalhostSuffix) && bytes.EqualFold(host[len(host)-len(bLocalhostSuffix):], bLocalhostSuffix)
+}
+
+// HostIsPrivate checks if host is a private address (10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16, fc00::/7).
+func (vec *Vector) HostIsPrivate() bool {
+	addr, ok := vec.HostAddr()
+	return ok && addr.Unmap().IsPrivate()
+}
+
+// HostIsLinkLocal checks if host is a link-local unicast or multicast address (169.254.0.0/16, fe80::/10, ...).
+func (vec *Vector) HostIsLinkLocal() bool {
+	addr, ok := vec.HostAddr()
+	return ok && (addr.Unmap().IsLinkLocalUnicast() || addr.Unmap().IsLinkLocalMulticast())
+}
+
+// HostIsMulticast checks if host is a multicast address.
+func (vec *Vector) HostIsMulticast() bool {
+	addr, ok := vec.HostAddr()
+	return ok && addr.Unmap().IsMulticast()
+}
+
+// HostIsUnspecified checks if host is an unspecified address (0.0.0.0, ::).
+func (vec *Vector) HostIsUnspecified() bool {
+	addr, ok := vec.HostAddr()
+	return ok && addr.Unmap().IsUnspecified()
+}
+
+// HostIsPublicIP checks if host is a globally reachable IP address, see IsPublicAddr().
+func (vec *Vector) HostIsPublicIP() bool {
+	addr, ok := vec.HostAddr()
+	return ok && IsPublicAddr(addr)
+}
+
+// IsPublicAddr checks if addr is a globally reachable unicast address, i.e. it isn't loopback, private, link-local,
+// multicast, unspecified, broadcast or other special purpose address (CGNAT, documentation, benchmarking, ...).
+// IPv4-mapped IPv6 addresses checks as IPv4.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for i := 0; i < len(nonPublicPrefixes); i++ {
+		if nonPublicPrefixes[i].Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse host as IP address.
+func parseHostAddr(host []byte) (netip.Addr, bool) {
+	if len(host) == 0 || len(host) > maxHostLen {
+		return netip.Addr{}, false
+	}
+	if bytealg.IndexByteAtBytes(host, '%', 0) >= 0 {
+		return parseEscapedHostAddr(host)
+	}
+	return parseRawHostAddr(host)
+}
+
+// Decode percent-encoded host and parse it as IP address.
+func parseEscapedHostAddr(host []byte) (netip.Addr, bool) {
+	var buf [maxHostLen]byte
+	return parseRawHostAddr(bufUnescape(buf[:0], host, modePath))
+}
+
+// Parse decoded host as IP address.
+func parseRawHostAddr(host []byte) (netip.Addr, bool) {
+	if len(host) == 0 {
+		return netip.Addr{}, false
+	}
+	if host[0] == '[' {
+		if len(host) < 2 || host[len(host)-1] != ']' {
+			return netip.Addr{}, false
+		}
+		return parseIPv6(host[1 : len(host)-1])
+	}
+	if host = trimDot(host); !endsInNumber(host) {
+		return netip.Addr{}, false
+	}
+	return parseIPv4(host)
+}
+
+// Parse IPv6 address. Zone copies since netip.Addr keeps it.
+func parseIPv6(p []byte) (netip.Addr, bool) {
+	var zone []byte
+	if i := bytealg.IndexByteAtBytes(p, '%', 0); i >= 0 {
+		p, zone = p[:i], p[i+1:]
+		if len(zone) == 0 {
+			return netip.Addr{}, false
+		}
+	}
+	addr, err := netip.ParseAddr(byteconv.B2S(p))
+	if err != nil || !addr.Is6() {
+		return netip.Addr{}, false
+	}
+	if len(zone) > 0 {
+		addr = addr.WithZone(string(zone))
+	}
+	return addr, true
+}
+
+// Parse IPv4 address according https://url.spec.whatwg.org/#concept-ipv4-parser.
+func parseIPv4(host []byte) (netip.Addr, bool) {
+	var (
+		parts [4]uint64
+		n     int
+	)
+	for lo := 0; ; {
+		hi := bytealg.IndexByteAtBytes(host, '.', lo)
+		if hi < 0 {
+			hi = len(host)
+		}
+		if n == len(parts) {
+			return netip.Addr{}, false
+		}
+		x, ok := parseIPv4Number(host[lo:hi])
+		if !ok {
+			return netip.Addr{}, false
+		}
+		parts[n] = x
+		n++
+		if hi == len(host) {
+			break
+		}
+		lo = hi + 1
+	}
+	// All parts except the last are octets, the last one fills the rest of address.
+	for i := 0; i < n-1; i++ {
+		if parts[i] > 255 {
+			return netip.Addr{}, false
+		}
+	}
+	if parts[n-1] >= 1<<(8*uint(5-n)) {
+		return netip.Addr{}, false
+	}
+	ip := parts[n-1]
+	for i := 0; i < n-1; i++ {
+		ip += parts[i] << (8 * uint(3-i))
+	}
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+// Parse part of IPv4 address: decimal, octal with leading zero or hexadecimal with 0x prefix.
+func parseIPv4Number(p []byte) (uint64, bool) {
+	if len(p) == 0 {
+		return 0, false
+	}
+	base := uint64(10)
+	if len(p) > 1 && p[0] == '0' {
+		if p[1] == 'x' || p[1] == 'X' {
+			base, p = 16, p[2:]
+		} else {
+			base, p = 8, p[1:]
+		}
+	}
+	var x uint64
+	for i := 0; i < len(p); i++ {
+		d := uint64(hex[p[i]])
+		if d >= base {
+			return 0, false
+		}
+		// Stop growing after overflow of IPv4 range, such number is invalid anyway.
+		if x = x*base + d; x > 1<<32 {
+			return 0, false
+		}
+	}
+	return x, true
+}
@@ -0,0 +1,138 @@
+package urlvector
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestHostAddr(t *testing.T) {
+	stages := []struct {
+		url, addr string
+	}{
+		{"http://127.0.0.1/", "127.0.0.1"},
+		{"http://2130706433/", "127.0.0.1"},
+		{"http://0x7f000001/", "127.0.0.1"},
+		{"http://0177.0.0.1/", "127.0.0.1"},
+		{"http://0x7f.1/", "127.0.0.1"},
+		{"http://127.1/", "127.0.0.1"},
+		{"http://127.0.1/", "127.0.0.1"},
+		{"http://0X7F.00.0x0.01/", "127.0.0.1"},
+		{"http://127.0.0.1./", "127.0.0.1"},
+		{"http://%31%32%37.0.0.1/", "127.0.0.1"},
+		{"http://0x/", "0.0.0.0"},
+		{"http://0/", "0.0.0.0"},
+		{"http://4294967295/", "255.255.255.255"},
+		{"http://169.254.169.254/latest/meta-data", "169.254.169.254"},
+		{"http://[::1]:8080/", "::1"},
+		{"http://[::ffff:127.0.0.1]/", "::ffff:127.0.0.1"},
+		{"http://[fe80::1%25eth0]/", "fe80::1%eth0"},
+		{"http://4294967296/", ""},
+		{"http://256.0.0.1/", ""},
+		{"http://1.2.3.4.5/", ""},
+		{"http://1.2.3.256/", ""},
+		{"http://1.2.65536/", ""},
+		{"http://08.0.0.1/", ""},
+		{"http://0xg.0.0.1/", ""},
+		{"http://1..1/", ""},
+		{"http://example.com/", ""},
+		{"http://1.2.3.example/", ""},
+		{"http://example.123/", ""},
+		{"http://[127.0.0.1]/", ""},
+		{"http://[::1/", ""},
+		{"mailto:user@example.com", ""},
+	}
+	for _, stage := range stages {
+		vec.Reset()
+		if err := vec.ParseString(stage.url); err != nil {
+			t.Error(stage.url, err)
+			continue
+		}
+		addr, ok := vec.HostAddr()
+		if ok != (len(stage.addr) > 0) {
+			t.Error(stage.url, "host address mismatch", "need", stage.addr, "got", addr, ok)
+			continue
+		}
+		if ok && addr != netip.MustParseAddr(stage.addr) {
+			t.Error(stage.url, "host address mismatch", "need", stage.addr, "got", addr)
+		}
+	}
+}
+
+func TestHostClass(t *testing.T) {
+	const (
+		loopback = 1 << iota
+		private
+		linkLocal
+		multicast
+		unspecified
+		public
+	)
+	stages := []struct {
+		url   string
+		class int
+	}{
+		{"http://127.0.0.1/", loopback},
+		{"http://2130706433/", loopback},
+		{"http://[::1]/", loopback},
+		{"http://[::ffff:7f00:1]/", loopback},
+		{"http://localhost:8080/", loopback},
+		{"http://api.LOCALHOST./", loopback},
+		{"http://10.0.0.1/", private},
+		{"http://172.16.5.4/", private},
+		{"http://0xc0a80001/", private},
+		{"http://[fd00::1]/", private},
+		{"http://169.254.169.254/", linkLocal},
+		{"http://[fe80::1]/", linkLocal},
+		{"http://224.0.0.1/", multicast | linkLocal},
+		{"http://239.1.2.3/", multicast},
+		{"http://[ff02::1]/", multicast | linkLocal},
+		{"http://0.0.0.0/", unspecified},
+		{"http://0/", unspecified},
+		{"http://[::]/", unspecified},
+		{"http://8.8.8.8/", public},
+		{"http://134744072/", public},
+		{"http://[2606:4700::1111]/", public},
+		{"http://100.64.0.1/", 0},
+		{"http://192.0.2.1/", 0},
+		{"http://255.255.255.255/", 0},
+		{"http://[2001:db8::1]/", 0},
+		{"http://example.com/", 0},
+	}
+	for _, stage := range stages {
+		vec.Reset()
+		_ = vec.ParseString(stage.url)
+		var class int
+		if vec.HostIsLoopback() {
+			class |= loopback
+		}
+		if vec.HostIsPrivate() {
+			class |= private
+		}
+		if vec.HostIsLinkLocal() {
+			class |= linkLocal
+		}
+		if vec.HostIsMulticast() {
+			class |= multicast
+		}
+		if vec.HostIsUnspecified() {
+			class |= unspecified
+		}
+		if vec.HostIsPublicIP() {
+			class |= public
+		}
+		if class != stage.class {
+			t.Error(stage.url, "host class mismatch", "need", stage.class, "got", class)
+		}
+	}
+}
+
+func BenchmarkHostAddr(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vec.Reset()
+		_ = vec.ParseString("http://0x7f.1/")
+		if addr, ok := vec.HostAddr(); !ok || !addr.IsLoopback() || vec.HostIsPublicIP() {
+			b.Error("host address mismatch")
+		}
+	}
+}
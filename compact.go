@@ -0,0 +1,138 @@
+package urlvector
+
+import "github.com/koykov/vector"
+
+// Live region of the buffer and its shift after compaction.
+type span struct {
+	lo, hi, shift int
+}
+
+// SetCompactThreshold enables automatic compaction (see Compact()) by setters when the buffer grows more than n bytes
+// since the last compaction. Zero n disables automatic compaction. Threshold drops by Reset().
+//
+// Each compaction allocates a new buffer, so results got before stay valid.
+func (vec *Vector) SetCompactThreshold(n int) *Vector {
+	vec.cth = n
+	return vec
+}
+
+// Compact rewrites bytes used by nodes and the source to a fresh contiguous region and drops the rest of the buffer:
+// values of replaced parts, assembled URLs, etc.
+//
+// Compaction is useful for long-lived vectors used as URL builders. Compacted bytes writes to a newly allocated
+// buffer and the old one never reuses, so slices and strings got from the vector before compaction (Bytes(),
+// PathBytes(), DialArgs(), ...) keep their contents, but they aren't tracked by the vector anymore.
+func (vec *Vector) Compact() {
+	if vec.Len() == 0 {
+		return
+	}
+	// Buffer may be grown by Bytes(), so re-address all buffer sourced nodes.
+	vec.SetBit(flagBufMod, true)
+	buf, src, n := vec.Buf(), vec.Src(), vec.Len()
+
+	spans := vec.spans[:0]
+	if off := ptrOffset(buf, src); off >= 0 {
+		spans = append(spans, span{lo: off, hi: off + len(src)})
+	}
+	for i := 0; i < n; i++ {
+		node := vec.nodeAt(i)
+		spans = appendSpan(spans, buf, node.Key())
+		spans = appendSpan(spans, buf, node.Value())
+	}
+	spans = mergeSpans(spans)
+
+	// Write live regions to the new buffer. The old one may be still referenced by results given before, so it
+	// mustn't be reused.
+	var live int
+	for i := range spans {
+		live += spans[i].hi - spans[i].lo
+	}
+	dst := make([]byte, 0, live+vec.cth)
+	for i := range spans {
+		spans[i].shift = spans[i].lo - len(dst)
+		dst = append(dst, buf[spans[i].lo:spans[i].hi]...)
+	}
+	vec.BufReplaceWith(dst)
+
+	for i := 0; i < n; i++ {
+		node := vec.GetByIdx(i)
+		readdr(spans, buf, dst, node.Key())
+		readdr(spans, buf, dst, node.Value())
+	}
+	if off := ptrOffset(buf, src); off >= 0 {
+		off -= findSpan(spans, off).shift
+		_ = vec.SetSrc(dst[off:off+len(src)], false)
+	}
+
+	vec.spans = spans
+	vec.touch()
+	vec.clen = len(dst)
+}
+
+// Compact the buffer if it grows over threshold.
+func (vec *Vector) checkCompact() {
+	if vec.cth > 0 && vec.BufLen()-vec.clen > vec.cth {
+		vec.Compact()
+	}
+}
+
+// Append region of buf pointed by p to spans.
+func appendSpan(spans []span, buf []byte, p *vector.Byteptr) []span {
+	raw := p.RawBytes()
+	if off := ptrOffset(buf, raw); off >= 0 {
+		spans = append(spans, span{lo: off, hi: off + len(raw)})
+	}
+	return spans
+}
+
+// Sort spans and merge overlapping ones.
+func mergeSpans(spans []span) []span {
+	// Insertion sort, spans are mostly sorted since nodes points to the buffer in order of writing.
+	for i := 1; i < len(spans); i++ {
+		for j := i; j > 0 && spans[j].lo < spans[j-1].lo; j-- {
+			spans[j], spans[j-1] = spans[j-1], spans[j]
+		}
+	}
+	var j int
+	for i := 1; i < len(spans); i++ {
+		if spans[i].lo <= spans[j].hi {
+			if spans[i].hi > spans[j].hi {
+				spans[j].hi = spans[i].hi
+			}
+			continue
+		}
+		j++
+		spans[j] = spans[i]
+	}
+	if len(spans) > 0 {
+		spans = spans[:j+1]
+	}
+	return spans
+}
+
+// Find span containing offset.
+func findSpan(spans []span, off int) span {
+	lo, hi := 0, len(spans)
+	for lo < hi {
+		m := (lo + hi) / 2
+		if spans[m].hi <= off {
+			lo = m + 1
+		} else {
+			hi = m
+		}
+	}
+	return spans[lo]
+}
+
+// Point p from old buffer to the same bytes in compacted buffer.
+func readdr(spans []span, old, buf []byte, p *vector.Byteptr) {
+	raw := p.RawBytes()
+	off := ptrOffset(old, raw)
+	if off < 0 {
+		return
+	}
+	esc, bsrc := p.CheckBit(flagEscape), p.CheckBit(flagBufSrc)
+	p.Init(buf, off-findSpan(spans, off).shift, len(raw))
+	p.SetBit(flagEscape, esc)
+	p.SetBit(flagBufSrc, bsrc)
+}
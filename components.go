@@ -0,0 +1,151 @@
+package urlvector
+
+import "github.com/koykov/vector"
+
+// Component represents bitmask of URL components to serialize, see AppendComponents().
+type Component uint
+
+const (
+	ComponentScheme Component = 1 << iota
+	ComponentUserinfo
+	ComponentHost
+	ComponentPort
+	ComponentPath
+	ComponentQuery
+	ComponentFragment
+
+	// ComponentAll selects the whole URL.
+	ComponentAll = ComponentScheme | ComponentUserinfo | ComponentHost | ComponentPort | ComponentPath | ComponentQuery |
+		ComponentFragment
+	// ComponentOrigin selects scheme, host and port (https://example.com:8443).
+	ComponentOrigin = ComponentScheme | ComponentHost | ComponentPort
+	// ComponentRequestURI selects path and query (/search?q=1).
+	ComponentRequestURI = ComponentPath | ComponentQuery
+)
+
+// AppendComponents appends URL components selected by mask to dst and returns the extended buffer. Vector keeps
+// untouched.
+//
+// Userinfo and port writes only together with host, slashes writes only together with scheme and host, so
+// ComponentAll &^ ComponentFragment gives URL without fragment, ComponentAll &^ ComponentUserinfo gives URL without
+// credentials and ComponentHost | ComponentPort gives host with port (example.com:8443).
+func (vec *Vector) AppendComponents(dst []byte, mask Component) []byte {
+	return vec.appendComponents(dst, mask, false, nil)
+}
+
+// Append URL components selected by mask to dst. Non-nil redaction policy masks credentials and sensitive query values.
+func (vec *Vector) appendComponents(dst []byte, mask Component, esc bool, rp *RedactPolicy) []byte {
+	if vec.Len() == 0 {
+		return dst
+	}
+	host := mask&ComponentHost != 0
+
+	opaque := vec.CheckBit(flagOpaque)
+	if mask&ComponentScheme != 0 {
+		if scheme := vec.SchemeBytes(); len(scheme) > 0 {
+			dst = append(dst, scheme...)
+			if opaque || !host {
+				dst = append(dst, bColon...)
+			} else {
+				dst = append(dst, bSchemaSep...)
+			}
+		} else if host && vec.Slashes() {
+			dst = append(dst, bSlashes...)
+		}
+	}
+
+	if host && mask&ComponentUserinfo != 0 {
+		if username, password := vec.UsernameBytes(), vec.PasswordBytes(); len(username) > 0 || len(password) > 0 {
+			if rp != nil && rp.Username && len(username) > 0 {
+				dst = rp.appendMask(dst)
+			} else {
+				dst = append(dst, username...)
+			}
+			if len(password) > 0 || hasEmptyTail(vec.AuthBytes(), username) {
+				dst = append(dst, bColon...)
+				if rp != nil && len(password) > 0 {
+					dst = rp.appendMask(dst)
+				} else {
+					dst = append(dst, password...)
+				}
+			}
+			dst = append(dst, bAt...)
+		} else if auth := vec.AuthBytes(); len(auth) > 0 {
+			// Empty user info (http://:@host).
+			dst = append(dst, auth...)
+			dst = append(dst, bAt...)
+		}
+	}
+
+	if host {
+		withPort := mask&ComponentPort != 0
+		if vec.opts&OptionDSN != 0 && vec.Hosts().Limit() > 1 {
+			vec.Hosts().Each(func(idx int, node *vector.Node) {
+				if idx > 0 {
+					dst = append(dst, bComma...)
+				}
+				dst = append(dst, node.Get("hostname").Bytes()...)
+				if port := node.Get("port"); withPort && port.Value().Len() > 0 {
+					dst = append(dst, bColon...)
+					dst = append(dst, port.Bytes()...)
+				}
+			})
+		} else if hostname, port := vec.HostnameBytes(), vec.getByIdx(idxPort); len(hostname) > 0 || port.Value().Len() > 0 {
+			dst = append(dst, hostname...)
+			if withPort && (port.Value().Len() > 0 || hasEmptyTail(vec.HostBytes(), hostname)) {
+				dst = append(dst, bColon...)
+				dst = append(dst, port.Bytes()...)
+			}
+		}
+	}
+
+	if path := vec.Path().Value(); mask&ComponentPath != 0 && path.Len() > 0 {
+		raw := path.RawBytes()
+		if vec.CheckBit(flagSCP) {
+			if host {
+				dst = append(dst, bColon...)
+			}
+		} else if raw[0] != '/' && raw[0] != '\\' && !opaque {
+			dst = append(dst, bSlash...)
+		}
+		// Escaped path writes as is to keep encoded reserved characters (%2F, %3F, ...).
+		if esc && !opaque && !path.CheckBit(flagEscape) {
+			dst = bufEscape(dst, raw, modePath)
+		} else {
+			dst = append(dst, raw...)
+		}
+	}
+
+	if mask&ComponentQuery != 0 {
+		if !vec.CheckBit(flagQueryParsed) {
+			if query := vec.QueryBytes(); len(query) > 0 {
+				if query[0] != '?' {
+					dst = append(dst, bQM...)
+				}
+				if rp != nil {
+					dst = rp.appendQuery(dst, query)
+				} else {
+					dst = append(dst, query...)
+				}
+			}
+		} else if query := vec.getByIdx(idxQuery); query.Limit() > 0 {
+			dst = appendQuery(dst, query, rp)
+		}
+	}
+
+	if hash := vec.HashBytes(); mask&ComponentFragment != 0 && len(hash) > 0 {
+		if hash[0] != '#' {
+			dst = append(dst, bHash...)
+		}
+		switch {
+		case rp != nil:
+			dst = rp.appendFragment(dst, hash)
+		case esc:
+			dst = bufEscape(dst, hash, modeHash)
+		default:
+			dst = append(dst, hash...)
+		}
+	}
+
+	return dst
+}
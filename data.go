@@ -0,0 +1,178 @@
+package urlvector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/vector"
+)
+
+var (
+	bBase64 = []byte("base64")
+
+	// DataPayloadLimit limits size of decoded data URL payload.
+	DataPayloadLimit = 32 << 20
+
+	ErrNotData      = errors.New("URL isn't a valid data URL")
+	ErrDataTooLarge = errors.New("data URL payload exceeds limit")
+	ErrBadBase64    = errors.New("data URL contains malformed base64 payload")
+)
+
+// Data returns data URL node (https://www.rfc-editor.org/rfc/rfc2397) with the following children:
+// * mediatype - media type (text/plain if omitted)
+// * params - object of media type parameters (charset, ...)
+// * base64 - flag indicates base64 encoded payload
+//
+// Note that data URL will be parsed by first attempt to access it.
+func (vec *Vector) Data() *vector.Node {
+	return vec.opaque()
+}
+
+// DataMediaType returns media type node of data URL.
+func (vec *Vector) DataMediaType() *vector.Node {
+	return vec.Data().Get("mediatype")
+}
+
+// DataParams returns media type parameters node of data URL.
+func (vec *Vector) DataParams() *vector.Node {
+	return vec.Data().Get("params")
+}
+
+// DataBase64 checks if data URL payload is base64 encoded.
+func (vec *Vector) DataBase64() bool {
+	return vec.Data().Get("base64").Bool()
+}
+
+// DataPayload decodes payload of data URL and appends it to dst.
+//
+// Size of decoded payload is limited by DataPayloadLimit.
+func (vec *Vector) DataPayload(dst []byte) ([]byte, error) {
+	if vec.Data().Limit() == 0 {
+		return dst, ErrNotData
+	}
+	path := vec.Path().Value().RawBytes()
+	payload := path[bytealg.IndexByteAtBytes(path, ',', 0)+1:]
+	query := vec.QueryBytes()
+
+	if !vec.DataBase64() {
+		if unescapedLen(payload)+unescapedLen(query) > DataPayloadLimit {
+			return dst, ErrDataTooLarge
+		}
+		dst = bufUnescape(dst, payload, modePath)
+		dst = bufUnescape(dst, query, modePath)
+		return dst, nil
+	}
+
+	if len(query) > 0 {
+		return dst, ErrBadBase64
+	}
+	if base64.RawStdEncoding.DecodedLen(unescapedLen(payload)) > DataPayloadLimit+2 {
+		return dst, ErrDataTooLarge
+	}
+	// Unescape payload and remove whitespaces (forgiving-base64, see https://infra.spec.whatwg.org/#forgiving-base64).
+	o := len(dst)
+	dst = bufUnescape(dst, payload, modePath)
+	m := o
+	for i := o; i < len(dst); i++ {
+		if c := dst[i]; c != ' ' && c != '\t' && c != '\n' && c != '\f' && c != '\r' {
+			dst[m] = c
+			m++
+		}
+	}
+	if (m-o)%4 == 0 && m > o && dst[m-1] == '=' {
+		m--
+		if dst[m-1] == '=' {
+			m--
+		}
+	}
+	n := base64.RawStdEncoding.DecodedLen(m - o)
+	if n > DataPayloadLimit {
+		return dst[:o], ErrDataTooLarge
+	}
+	// Decode to the tail of dst and move result to the payload's origin position.
+	dst = bytealg.Grow(dst[:m], m+n)
+	n, err := base64.RawStdEncoding.Decode(dst[m:], dst[o:m])
+	if err != nil {
+		return dst[:o], ErrBadBase64
+	}
+	copy(dst[o:], dst[m:m+n])
+	return dst[:o+n], nil
+}
+
+// Parse data URL's opaque path to media type, params and base64 flag.
+func (vec *Vector) parseData(node *vector.Node) {
+	origin := vec.Path().Value().RawBytes()
+	comma := bytealg.IndexByteAtBytes(origin, ',', 0)
+	if comma < 0 {
+		return
+	}
+	node.Key().Init(bKeys, offsetData, lenData)
+	node.SetOffset(vec.Index.Len(2))
+
+	mediatype, im := vec.AcquireChildWithType(node, 2, vector.TypeString)
+	mediatype.Key().Init(bKeys, offsetMediaType, lenMediaType)
+	params, ip := vec.AcquireChildWithType(node, 2, vector.TypeObject)
+	params.Key().Init(bKeys, offsetParams, lenParams)
+	params.SetOffset(vec.Index.Len(3))
+	b64, ib := vec.AcquireChildWithType(node, 2, vector.TypeBool)
+	b64.Key().Init(bKeys, offsetBase64, lenBase64)
+
+	var c int
+	for offset := 0; offset < comma; c++ {
+		i := bytealg.IndexByteAtBytes(origin[:comma], ';', offset)
+		if i < 0 {
+			i = comma
+		}
+		lo, hi := trimSpaceIdx(origin, offset, i)
+		part := origin[lo:hi]
+		switch {
+		case c == 0:
+			if len(part) > 0 {
+				vec.initPtr(mediatype.Value(), origin, lo, hi-lo)
+			}
+		case i == comma && bytes.EqualFold(part, bBase64):
+			b64.Value().Init(bKeys, offsetTrue, lenTrue)
+		default:
+			if j := bytealg.IndexByteAtBytes(part, '=', 0); j > 0 {
+				param, k := vec.AcquireChildWithType(params, 3, vector.TypeString)
+				vec.initPtr(param.Key(), origin, lo, j)
+				vec.initPtr(param.Value(), origin, lo+j+1, len(part)-j-1)
+				vec.ReleaseNode(k, param)
+			}
+		}
+		offset = i + 1
+	}
+	if mediatype.Value().Len() == 0 {
+		mediatype.Value().Init(bKeys, offsetTextPlain, lenTextPlain)
+	}
+
+	vec.ReleaseNode(im, mediatype)
+	vec.ReleaseNode(ip, params)
+	vec.ReleaseNode(ib, b64)
+	vec.ReleaseNode(node.Index(), node)
+}
+
+// Get length of p after unescape.
+func unescapedLen(p []byte) int {
+	n := len(p)
+	for i := 0; i < len(p)-2; i++ {
+		if p[i] == '%' && hex[p[i+1]] != 16 && hex[p[i+2]] != 16 {
+			n -= 2
+			i += 2
+		}
+	}
+	return n
+}
+
+// Get bounds of p[lo:hi] without leading and trailing spaces.
+func trimSpaceIdx(p []byte, lo, hi int) (int, int) {
+	for lo < hi && p[lo] == ' ' {
+		lo++
+	}
+	for hi > lo && p[hi-1] == ' ' {
+		hi--
+	}
+	return lo, hi
+}
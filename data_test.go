@@ -0,0 +1,122 @@
+package urlvector
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestData(t *testing.T) {
+	t.Run("base64", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("data:image/png;name=x.png;base64,aGVsbG8g d29y\nbGQ=")
+		if s := vec.SchemeString(); s != "data" {
+			t.Error("scheme mismatch", "need", "data", "got", s)
+		}
+		if h := vec.HostString(); h != "" {
+			t.Error("unexpected host", h)
+		}
+		if mt := vec.DataMediaType().String(); mt != "image/png" {
+			t.Error("media type mismatch", "need", "image/png", "got", mt)
+		}
+		if n := vec.DataParams().GetString("name"); n != "x.png" {
+			t.Error("param mismatch", "need", "x.png", "got", n)
+		}
+		if !vec.DataBase64() {
+			t.Error("base64 flag expected")
+		}
+		payload, err := vec.DataPayload([]byte("prefix:"))
+		if err != nil || string(payload) != "prefix:hello world" {
+			t.Error("payload mismatch", "need", "prefix:hello world", "got", string(payload), err)
+		}
+	})
+
+	t.Run("percent", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("data:text/plain;charset=UTF-8,Hello%2C%20World!#frag")
+		if cs := vec.DataParams().GetString("charset"); cs != "UTF-8" {
+			t.Error("charset mismatch", "need", "UTF-8", "got", cs)
+		}
+		if vec.DataBase64() {
+			t.Error("unexpected base64 flag")
+		}
+		payload, err := vec.DataPayload(nil)
+		if err != nil || string(payload) != "Hello, World!" {
+			t.Error("payload mismatch", "need", "Hello, World!", "got", string(payload), err)
+		}
+		if h := vec.HashString(); h != "#frag" {
+			t.Error("hash mismatch", "need", "#frag", "got", h)
+		}
+		if s := vec.String(); s != "data:text/plain;charset=UTF-8,Hello%2C%20World!#frag" {
+			t.Error("serialize mismatch", "got", s)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("data:,foo")
+		if mt := vec.DataMediaType().String(); mt != "text/plain" {
+			t.Error("media type mismatch", "need", "text/plain", "got", mt)
+		}
+	})
+
+	t.Run("buffer growth", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("data:,")
+		vec.SetPathString("text/plain;charset=utf-8,hi")
+		_ = vec.Data()
+		// Reallocate the buffer after parsing.
+		vec.SetHashString(strings.Repeat("h", 4096))
+		if mt := vec.DataMediaType().String(); mt != "text/plain" {
+			t.Error("media type mismatch", "need", "text/plain", "got", mt)
+		}
+		if cs := vec.DataParams().GetString("charset"); cs != "utf-8" {
+			t.Error("charset mismatch", "need", "utf-8", "got", cs)
+		}
+		testAddr(t, vec, vec.Data())
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		limit := DataPayloadLimit
+		defer func() { DataPayloadLimit = limit }()
+		DataPayloadLimit = 4
+
+		vec.Reset()
+		_ = vec.ParseString("data:;base64,aGVsbG8gd29ybGQ=")
+		if _, err := vec.DataPayload(nil); err != ErrDataTooLarge {
+			t.Error("error mismatch", "need", ErrDataTooLarge, "got", err)
+		}
+		vec.Reset()
+		_ = vec.ParseString("data:,0123456789")
+		if _, err := vec.DataPayload(nil); err != ErrDataTooLarge {
+			t.Error("error mismatch", "need", ErrDataTooLarge, "got", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("data:;base64,a")
+		if _, err := vec.DataPayload(nil); err != ErrBadBase64 {
+			t.Error("error mismatch", "need", ErrBadBase64, "got", err)
+		}
+		vec.Reset()
+		_ = vec.ParseString("http://example.com/data:,foo")
+		if _, err := vec.DataPayload(nil); err != ErrNotData {
+			t.Error("error mismatch", "need", ErrNotData, "got", err)
+		}
+	})
+}
+
+func BenchmarkData(b *testing.B) {
+	src := "data:text/plain;charset=utf-8;base64,aGVsbG8gd29ybGQ="
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vec.Reset()
+		_ = vec.ParseString(src)
+		buf, _ = vec.DataPayload(buf[:0])
+		if !bytes.Equal(buf, []byte("hello world")) {
+			b.Error("payload mismatch", "got", string(buf))
+		}
+	}
+}
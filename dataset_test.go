@@ -2,6 +2,10 @@ package urlvector
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -38,6 +42,47 @@ func init() {
 		}
 		_ = file.Close()
 	}
+
+	// WHATWG conformance cases (https://github.com/web-platform-tests/wpt/blob/master/url/resources/urltestdata.json):
+	// list of objects mixed with comment strings. Only cases without base URL are applicable.
+	raw, err := os.ReadFile("dataset/urltestdata.json")
+	if err != nil {
+		return
+	}
+	var list []json.RawMessage
+	if err = json.Unmarshal(raw, &list); err != nil {
+		return
+	}
+	for i := 0; i < len(list); i++ {
+		var c conformanceCase
+		if json.Unmarshal(list[i], &c) == nil && c.Base == nil {
+			conformanceCases = append(conformanceCases, c)
+		}
+	}
+}
+
+type conformanceCase struct {
+	Input    string  `json:"input"`
+	Base     *string `json:"base"`
+	Href     string  `json:"href"`
+	Protocol string  `json:"protocol"`
+	Username string  `json:"username"`
+	Password string  `json:"password"`
+	Host     string  `json:"host"`
+	Hostname string  `json:"hostname"`
+	Port     string  `json:"port"`
+	Pathname string  `json:"pathname"`
+	Search   string  `json:"search"`
+	Hash     string  `json:"hash"`
+	Failure  bool    `json:"failure"`
+}
+
+var conformanceCases []conformanceCase
+
+// Known divergences from net/url by design.
+var netURLSkip = []string{
+	// Blob prefix strips by parser.
+	"blob:",
 }
 
 func TestDataset(t *testing.T) {
@@ -53,6 +98,245 @@ func TestDataset(t *testing.T) {
 	}
 }
 
+func TestConformance(t *testing.T) {
+	vec := NewVector()
+	var (
+		used     [len(conformanceRules)]int
+		diverged int
+	)
+	for i := 0; i < len(conformanceCases); i++ {
+		c := &conformanceCases[i]
+		vec.Reset()
+		diff, desc := conformanceDiff(vec, c)
+		if diff == 0 {
+			if !c.Failure {
+				assertRoundTrip(t, vec)
+			}
+			continue
+		}
+		// Divergence is known if every mismatched part is explained by rule matching the input.
+		in := splitConformanceInput(c.Input)
+		var known conformancePart
+		for j := 0; j < len(conformanceRules); j++ {
+			if r := &conformanceRules[j]; diff&r.parts != 0 && r.match(&in) {
+				known |= r.parts
+				used[j]++
+			}
+		}
+		if diff&^known != 0 {
+			t.Errorf("%q: %s", c.Input, desc)
+			continue
+		}
+		diverged++
+	}
+	for i := 0; i < len(conformanceRules); i++ {
+		if used[i] == 0 {
+			t.Errorf("rule %q explains no divergence", conformanceRules[i].name)
+		}
+		t.Logf("%3d cases: %s", used[i], conformanceRules[i].name)
+	}
+	t.Logf("%d of %d cases diverge by known rules", diverged, len(conformanceCases))
+}
+
+// Parse conformance case and get mismatched parts with description of them.
+func conformanceDiff(vec *Vector, c *conformanceCase) (diff conformancePart, desc string) {
+	err := vec.ParseString(c.Input)
+	switch {
+	case c.Failure && err == nil:
+		return partFailure, "failure expected"
+	case c.Failure:
+		return 0, ""
+	case err != nil:
+		return partFailure, err.Error()
+	}
+	var protocol string
+	if scheme := vec.SchemeString(); len(scheme) > 0 {
+		protocol = scheme + ":"
+	}
+	// Search and hash getters returns empty string for empty query and fragment.
+	search, hash := vec.QueryString(), vec.HashString()
+	if search == "?" {
+		search = ""
+	}
+	if hash == "#" {
+		hash = ""
+	}
+	components := [...]struct {
+		part              conformancePart
+		name, got, expect string
+	}{
+		{partProtocol, "protocol", protocol, c.Protocol},
+		{partUsername, "username", vec.UsernameString(), c.Username},
+		{partPassword, "password", vec.PasswordString(), c.Password},
+		{partHost, "host", vec.HostString(), c.Host},
+		{partHostname, "hostname", vec.HostnameString(), c.Hostname},
+		{partPort, "port", vec.getByIdx(idxPort).String(), c.Port},
+		{partPathname, "pathname", vec.Path().Value().String(), c.Pathname},
+		{partSearch, "search", search, c.Search},
+		{partHash, "hash", hash, c.Hash},
+		{partHref, "href", vec.String(), c.Href},
+	}
+	for i := 0; i < len(components); i++ {
+		if cm := &components[i]; cm.got != cm.expect {
+			diff |= cm.part
+			if len(desc) > 0 {
+				desc += ", "
+			}
+			desc += fmt.Sprintf("%s mismatch: got %q, expect %q", cm.name, cm.got, cm.expect)
+		}
+	}
+	return
+}
+
+func TestNetURL(t *testing.T) {
+	vec := NewVector()
+	for i := 0; i < len(dsStages); i++ {
+		src := dsStages[i].src
+		u, err := url.Parse(src)
+		if err != nil || (len(u.Scheme) == 0 && len(u.Host) == 0) || skipNetURL(src) {
+			// Scheme-less URLs considers by parser as host/path, net/url considers them as relative path.
+			continue
+		}
+		vec.Reset()
+		if err = vec.ParseString(src); err != nil {
+			t.Errorf("%q: %s", src, err)
+			continue
+		}
+		assertComponent(t, src, "scheme", strings.ToLower(vec.SchemeString()), u.Scheme)
+		if u.User != nil {
+			assertComponent(t, src, "username", string(PathUnescape(nil, vec.UsernameBytes())), u.User.Username())
+			password, _ := u.User.Password()
+			assertComponent(t, src, "password", string(PathUnescape(nil, vec.PasswordBytes())), password)
+		}
+		if !strings.Contains(u.Host, "%") {
+			// net/url unescapes IPv6 zone.
+			assertComponent(t, src, "host", vec.HostString(), u.Host)
+		}
+		if len(u.Opaque) > 0 {
+			assertComponent(t, src, "opaque", vec.Path().String(), u.Opaque)
+		} else {
+			assertComponent(t, src, "path", vec.PathString(), u.Path)
+		}
+		assertComponent(t, src, "query", strings.TrimPrefix(vec.QueryString(), "?"), u.RawQuery)
+		assertComponent(t, src, "fragment", string(PathUnescape(nil, bytes.TrimPrefix(vec.HashBytes(), bHash))), u.Fragment)
+		assertRoundTrip(t, vec)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	fuzzSeed(f)
+	vec := NewVector()
+	f.Fuzz(func(t *testing.T, src string) {
+		vec.Reset()
+		if err := vec.ParseString(src); err != nil {
+			return
+		}
+		_ = vec.PathBytes()
+		_ = vec.Query()
+		_ = vec.Fragment()
+		_ = vec.Hosts()
+		_ = vec.Bytes()
+	})
+}
+
+func FuzzQueryUnescape(f *testing.F) {
+	f.Add("a+b%20c")
+	f.Add("%E2%82%AC")
+	f.Add("100%25")
+	f.Add("%zz%")
+	f.Fuzz(func(t *testing.T, src string) {
+		expect, err := url.QueryUnescape(src)
+		if err != nil {
+			// Invalid escape sequences keeps as is instead of error.
+			return
+		}
+		if got := QueryUnescape(nil, []byte(src)); string(got) != expect {
+			t.Errorf("%q: got %q, expect %q", src, got, expect)
+		}
+		if got := unescape([]byte(src), modeQuery); string(got) != expect {
+			t.Errorf("%q: in-place got %q, expect %q", src, got, expect)
+		}
+	})
+}
+
+func FuzzBytes(f *testing.F) {
+	fuzzSeed(f)
+	vec, cpy := NewVector(), NewVector()
+	f.Fuzz(func(t *testing.T, src string) {
+		if strings.IndexByte(src, ' ') >= 0 {
+			// Space isn't allowed in URL and trims by parser, so components may lose it after serialization.
+			return
+		}
+		vec.Reset()
+		if err := vec.ParseString(src); err != nil {
+			return
+		}
+		// Serialization normalizes some parts (drops empty port, ...), so it must be idempotent.
+		b := vec.String()
+		if len(b) == 0 {
+			return
+		}
+		cpy.Reset()
+		if err := cpy.ParseString(b); err != nil {
+			t.Errorf("%q: round trip error %s", b, err)
+			return
+		}
+		if cpy.String() != b {
+			t.Errorf("%q: round trip mismatch: got %q", b, cpy.String())
+		}
+	})
+}
+
+// Check if serialized URL parses to the same components.
+func assertRoundTrip(t *testing.T, vec *Vector) {
+	t.Helper()
+	src := vec.String()
+	if len(src) == 0 {
+		// Nothing to parse, e.g. empty port drops from ":".
+		return
+	}
+	cpy := NewVector()
+	cpy.SetOptions(vec.Options())
+	if err := cpy.ParseString(src); err != nil {
+		t.Errorf("%q: round trip error %s", src, err)
+		return
+	}
+	assertComponent(t, src, "round trip scheme", cpy.SchemeString(), vec.SchemeString())
+	assertComponent(t, src, "round trip username", cpy.UsernameString(), vec.UsernameString())
+	assertComponent(t, src, "round trip password", cpy.PasswordString(), vec.PasswordString())
+	assertComponent(t, src, "round trip hostname", cpy.HostnameString(), vec.HostnameString())
+	assertComponent(t, src, "round trip port", cpy.getByIdx(idxPort).String(), vec.getByIdx(idxPort).String())
+	assertComponent(t, src, "round trip path", cpy.PathString(), vec.PathString())
+	assertComponent(t, src, "round trip query", cpy.QueryString(), vec.QueryString())
+	assertComponent(t, src, "round trip hash", cpy.HashString(), vec.HashString())
+	assertComponent(t, src, "round trip", cpy.String(), src)
+}
+
+func assertComponent(t *testing.T, src, name, got, expect string) {
+	t.Helper()
+	if got != expect {
+		t.Errorf("%q: %s mismatch: got %q, expect %q", src, name, got, expect)
+	}
+}
+
+func skipNetURL(src string) bool {
+	for i := 0; i < len(netURLSkip); i++ {
+		if strings.HasPrefix(src, netURLSkip[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func fuzzSeed(f *testing.F) {
+	for i := 0; i < len(dsStages); i++ {
+		f.Add(dsStages[i].src)
+	}
+	for i := 0; i < len(conformanceCases); i++ {
+		f.Add(conformanceCases[i].Input)
+	}
+}
+
 func BenchmarkDataset(b *testing.B) {
 	if len(dsStages) == 0 {
 		return
@@ -66,3 +350,157 @@ func BenchmarkDataset(b *testing.B) {
 		Release(vec)
 	}
 }
+
+// Parts of URL compared by conformance test.
+type conformancePart uint
+
+const (
+	partFailure conformancePart = 1 << iota
+	partProtocol
+	partUsername
+	partPassword
+	partHost
+	partHostname
+	partPort
+	partPathname
+	partSearch
+	partHash
+	partHref
+
+	partAll       = partHref<<1 - 1
+	partAuthority = partUsername | partPassword | partHost | partHostname | partPort
+	// Parts affected by percent-encoding.
+	partEncoded = partUsername | partPassword | partHost | partHostname | partPathname | partSearch | partHash | partHref
+)
+
+// Conformance input roughly split to parts to match divergence rules.
+type conformanceInput struct {
+	// Raw input and its part after scheme.
+	raw, rest                          string
+	scheme, userinfo, host, port, path string
+	special, authority, hasUserinfo    bool
+}
+
+func splitConformanceInput(raw string) (in conformanceInput) {
+	in.raw, in.rest = raw, raw
+	if n := schemeLen([]byte(raw)); n > 0 {
+		in.scheme, in.rest = strings.ToLower(raw[:n]), raw[n+1:]
+	}
+	spec, _ := LookupScheme([]byte(in.scheme))
+	in.special = spec.Special
+	s := in.rest
+	if in.authority = strings.HasPrefix(s, "//"); in.authority {
+		s = s[2:]
+		end := strings.IndexAny(s, "/?#")
+		if end < 0 {
+			end = len(s)
+		}
+		auth := s[:end]
+		s = s[end:]
+		if i := strings.LastIndexByte(auth, '@'); i >= 0 {
+			in.userinfo, in.hasUserinfo, auth = auth[:i], true, auth[i+1:]
+		}
+		in.host = auth
+		if i := strings.LastIndexByte(auth, ':'); i >= 0 && i > strings.LastIndexByte(auth, ']') {
+			in.host, in.port = auth[:i], auth[i+1:]
+		}
+	}
+	if end := strings.IndexAny(s, "?#"); end >= 0 {
+		s = s[:end]
+	}
+	in.path = s
+	return
+}
+
+// Known divergences from WHATWG URL standard. Parser keeps components raw as they appear in the source (no case
+// folding, percent-encoding, IPv4 and IDNA host normalization, dot segments and default port removal) and isn't a
+// validator. Each rule explains mismatches of given parts of inputs it matches, any other mismatch fails the test.
+var conformanceRules = [...]struct {
+	name  string
+	parts conformancePart
+	match func(in *conformanceInput) bool
+}{
+	{"relative reference parses without base URL", partFailure, func(in *conformanceInput) bool {
+		return len(in.scheme) == 0
+	}},
+	{"invalid host, port or user info is accepted", partFailure, func(in *conformanceInput) bool {
+		return in.authority || in.special
+	}},
+	{"blob: prefix is stripped", partAll, func(in *conformanceInput) bool {
+		return len(in.raw) >= 5 && strings.EqualFold(in.raw[:5], "blob:")
+	}},
+	{"C0 controls around input, tabs and newlines inside it aren't stripped", partAll,
+		func(in *conformanceInput) bool {
+			l := len(in.raw)
+			return strings.ContainsAny(in.raw, "\t\n\r") || l > 0 && (in.raw[0] <= ' ' || in.raw[l-1] <= ' ')
+		}},
+	{"code points aren't percent-encoded", partEncoded, func(in *conformanceInput) bool {
+		for i := 0; i < len(in.raw); i++ {
+			if c := in.raw[i]; c <= ' ' || c >= 0x7f || strings.IndexByte("\"'<>`{}", c) >= 0 {
+				return true
+			}
+		}
+		return false
+	}},
+	{"backslash isn't a separator in special URL", partAll &^ partFailure, func(in *conformanceInput) bool {
+		return in.special && strings.IndexByte(in.raw, '\\') >= 0
+	}},
+	{"special URL without slashes isn't recognized", partAll &^ partFailure, func(in *conformanceInput) bool {
+		return in.special && !in.authority && in.scheme != "file"
+	}},
+	{"non-special scheme followed by path or colon parses as host and port", partProtocol | partAuthority |
+		partPathname | partHref, func(in *conformanceInput) bool {
+		if len(in.scheme) == 0 || in.special || in.authority {
+			return false
+		}
+		end := strings.IndexAny(in.rest, "/?#")
+		if end < 0 {
+			end = len(in.rest)
+		}
+		return end == 0 || strings.ContainsAny(in.rest[:end], "@:")
+	}},
+	{"data: and mailto: with authority parse as opaque", partAuthority | partPathname | partHref,
+		func(in *conformanceInput) bool {
+			return in.authority && opaqueSchemeLen([]byte(in.raw)) > 0
+		}},
+	{"file URL host and empty path aren't normalized", partHost | partHostname | partPathname | partHref,
+		func(in *conformanceInput) bool {
+			return in.scheme == "file"
+		}},
+	{"empty path of special URL isn't replaced with /", partPathname | partHref, func(in *conformanceInput) bool {
+		return in.special && len(in.path) == 0
+	}},
+	{"dot segments aren't removed", partPathname | partHref, func(in *conformanceInput) bool {
+		for _, seg := range strings.Split(in.path, "/") {
+			seg = strings.ReplaceAll(strings.ToLower(seg), "%2e", ".")
+			if seg == "." || seg == ".." {
+				return true
+			}
+		}
+		return false
+	}},
+	{"default port isn't dropped", partHost | partPort | partHref, func(in *conformanceInput) bool {
+		return in.special && isDefaultPort(in.scheme, in.port)
+	}},
+	{"empty username or password isn't dropped", partUsername | partPassword | partHref,
+		func(in *conformanceInput) bool {
+			return in.hasUserinfo && (len(in.userinfo) == 0 || strings.HasSuffix(in.userinfo, ":"))
+		}},
+	{"IPv4 address in numeric form isn't normalized", partHost | partHostname | partHref,
+		func(in *conformanceInput) bool {
+			return in.special && len(in.host) > 0 && strings.Trim(in.host, "0123456789abcdefxABCDEFX.") == "" &&
+				strings.ContainsAny(in.host, "0123456789")
+		}},
+	{"IPv6 address isn't compressed", partHost | partHostname | partHref, func(in *conformanceInput) bool {
+		return strings.HasPrefix(in.host, "[")
+	}},
+	{"host isn't lowercased, IDNA or percent mapped", partHost | partHostname | partHref,
+		func(in *conformanceInput) bool {
+			for i := 0; i < len(in.host); i++ {
+				if c := in.host[i]; c >= 'A' && c <= 'Z' || c == '%' || c >= 0x80 {
+					return true
+				}
+			}
+			return false
+		}},
+}
@@ -0,0 +1,137 @@
+package urlvector
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/koykov/byteconv"
+)
+
+var ErrScanType = errors.New("unsupported scan source type, string or []byte expected")
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (vec *Vector) MarshalText() ([]byte, error) {
+	return vec.AppendBytes(nil), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+//
+// Vector resets before parsing, text copies since caller may reuse it.
+func (vec *Vector) UnmarshalText(text []byte) error {
+	vec.ensureInit()
+	vec.Reset()
+	return vec.ParseCopy(text)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler interface. Binary representation is the same as text.
+func (vec *Vector) MarshalBinary() ([]byte, error) {
+	return vec.MarshalText()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler interface.
+func (vec *Vector) UnmarshalBinary(data []byte) error {
+	return vec.UnmarshalText(data)
+}
+
+// Scan implements sql.Scanner interface.
+//
+// NULL resets the vector, source copies since driver may reuse it.
+func (vec *Vector) Scan(src any) error {
+	vec.ensureInit()
+	vec.Reset()
+	switch x := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return vec.ParseCopyString(x)
+	case []byte:
+		return vec.ParseCopy(x)
+	default:
+		return ErrScanType
+	}
+}
+
+// Value implements driver.Valuer interface. Empty vector stores as NULL.
+func (vec *Vector) Value() (driver.Value, error) {
+	vec.out = vec.AppendBytes(vec.out[:0])
+	if len(vec.out) == 0 {
+		return nil, nil
+	}
+	return string(vec.out), nil
+}
+
+// Format implements fmt.Formatter interface.
+//
+// Verbs %s and %v writes URL, %q writes quoted URL and %+v dumps non-empty URL components with credentials masked
+// according DefaultRedactPolicy. Vector buffer keeps untouched.
+func (vec *Vector) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		vec.out = vec.appendDump(vec.out[:0], &DefaultRedactPolicy)
+		_, _ = f.Write(vec.out)
+		return
+	}
+	vec.out = vec.AppendBytes(vec.out[:0])
+	switch verb {
+	case 's', 'v':
+		_, _ = f.Write(vec.out)
+	case 'q':
+		_, _ = fmt.Fprintf(f, "%q", vec.out)
+	default:
+		_, _ = fmt.Fprintf(f, "%%!%c(*urlvector.Vector=%s)", verb, vec.out)
+	}
+}
+
+// Append components in struct-like form to dst: {scheme:https host:example.com path:/}.
+func (vec *Vector) appendDump(dst []byte, rp *RedactPolicy) []byte {
+	dst = append(dst, '{')
+	if vec.Len() == 0 {
+		return append(dst, '}')
+	}
+	o := len(dst)
+	field := func(name string, val []byte) {
+		if len(val) == 0 {
+			return
+		}
+		if len(dst) > o {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, name...)
+		dst = append(dst, ':')
+		dst = append(dst, val...)
+	}
+	field("scheme", vec.SchemeBytes())
+	if username := vec.UsernameBytes(); len(username) > 0 && rp.Username {
+		field("username", byteconv.S2B(rp.mask()))
+	} else {
+		field("username", username)
+	}
+	if len(vec.PasswordBytes()) > 0 {
+		field("password", byteconv.S2B(rp.mask()))
+	}
+	field("hostname", vec.HostnameBytes())
+	field("port", vec.getByIdx(idxPort).Bytes())
+	field("path", vec.Path().Value().RawBytes())
+	// Query writes with the same rules as URL and drops if empty.
+	mark := len(dst)
+	if len(dst) > o {
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, "query:"...)
+	q := len(dst)
+	dst = vec.appendComponents(dst, ComponentQuery, false, rp)
+	switch {
+	case len(dst) <= q+1:
+		dst = dst[:mark]
+	case dst[q] == '?':
+		dst = append(dst[:q], dst[q+1:]...)
+	}
+	if hash := vec.HashBytes(); len(hash) > 1 {
+		if len(dst) > o {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, "hash:"...)
+		dst = rp.appendFragment(dst, hash[1:])
+	}
+	return append(dst, '}')
+}
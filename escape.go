@@ -3,6 +3,7 @@ package urlvector
 import (
 	"unsafe"
 
+	"github.com/koykov/bytealg"
 	"github.com/koykov/byteconv"
 )
 
@@ -13,6 +14,9 @@ const (
 	modeQuery
 	modeHash
 	modeURIComponent
+	modeMailto
+	modeFullPath
+	modeUserinfo
 
 	hex = "\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x00\x01\x02\x03\x04\x05\x06\a\b\t\x10\x10\x10\x10\x10\x10\x10\n\v\f\r\x0e\x0f\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\n\v\f\r\x0e\x0f\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10\x10"
 	// Hex digits in upper case.
@@ -25,6 +29,8 @@ func QueryEscape(dst, p []byte) []byte {
 }
 
 // QueryUnescape does the inverse transformation of QueryEscape.
+//
+// Invalid or truncated escape sequences (%zz, %4) keeps as is.
 func QueryUnescape(dst, p []byte) []byte {
 	return bufUnescape(dst, p, modeQuery)
 }
@@ -35,6 +41,8 @@ func PathEscape(dst, p []byte) []byte {
 }
 
 // PathUnescape does the inverse transformation of PathEscape.
+//
+// Plus sign keeps as is, invalid or truncated escape sequences keeps as well.
 func PathUnescape(dst, p []byte) []byte {
 	return bufUnescape(dst, p, modePath)
 }
@@ -49,33 +57,40 @@ func DecodeURIComponent(dst, p []byte) []byte {
 	return bufUnescape(dst, p, modeURIComponent)
 }
 
-// In-place unescape bytes.
-func unescape(p []byte) []byte {
-	l := len(p)
+// In-place unescape bytes according mode.
+//
+// Plus sign converts to space only in query mode, invalid escape sequences keeps as is.
+func unescape(p []byte, mode mode) []byte {
 	n := len(p)
-	if l < 3 {
-		return p
-	}
-	_ = p[l-1]
 	for i := 0; i < n; i++ {
 		switch p[i] {
 		case '%':
-			if i+2 < l {
-				x2 := hex[p[i+2]]
+			if i+2 < n {
 				x1 := hex[p[i+1]]
-				if x1 != 16 || x2 != 16 {
+				x2 := hex[p[i+2]]
+				if x1 != 16 && x2 != 16 {
 					p[i] = x1<<4 | x2
-					copy(p[i+1:], p[i+3:])
+					copy(p[i+1:n], p[i+3:n])
 					n -= 2
 				}
 			}
 		case '+':
-			p[i] = ' '
+			if mode == modeQuery {
+				p[i] = ' '
+			}
 		}
 	}
 	return p[:n]
 }
 
+// Check if p contains escape sequences or plus signs which unescape according mode.
+func needUnescape(p []byte, mode mode) bool {
+	if bytealg.IndexByteAtBytes(p, '%', 0) >= 0 {
+		return true
+	}
+	return mode != modePath && mode != modeMailto && bytealg.IndexByteAtBytes(p, '+', 0) >= 0
+}
+
 // Escape p to dst according mode.
 func bufEscape(dst, p []byte, mode mode) []byte {
 	l := len(p)
@@ -96,6 +111,19 @@ func bufEscape(dst, p []byte, mode mode) []byte {
 			allow = allow || p[i] == '#' || p[i] == '?' || p[i] == '=' || p[i] == '!' || p[i] == '(' || p[i] == ')' || p[i] == '*'
 		case modeURIComponent:
 			allow = allow || p[i] == '!' || p[i] == '~' || p[i] == '*' || p[i] == '\'' || p[i] == '(' || p[i] == ')'
+		case modeMailto:
+			allow = allow || p[i] == '~' || p[i] == '!' || p[i] == '$' || p[i] == '\'' || p[i] == '(' || p[i] == ')' ||
+				p[i] == '*' || p[i] == '+' || p[i] == ';' || p[i] == ':' || p[i] == '@' || p[i] == '/'
+		case modeFullPath:
+			// Path characters (RFC 3986 section 3.3) including slashes, valid escape sequences keeps as is.
+			allow = allow || p[i] == '~' || p[i] == '!' || p[i] == '$' || p[i] == '&' || p[i] == '\'' || p[i] == '(' ||
+				p[i] == ')' || p[i] == '*' || p[i] == '+' || p[i] == ',' || p[i] == ';' || p[i] == '=' || p[i] == ':' ||
+				p[i] == '@' || p[i] == '/' || p[i] == '%' && i+2 < l && hex[p[i+1]] != 16 && hex[p[i+2]] != 16
+		case modeUserinfo:
+			// Userinfo characters (RFC 3986 section 3.2.1) except colon, valid escape sequences keeps as is.
+			allow = allow || p[i] == '~' || p[i] == '!' || p[i] == '$' || p[i] == '&' || p[i] == '\'' || p[i] == '(' ||
+				p[i] == ')' || p[i] == '*' || p[i] == '+' || p[i] == ',' || p[i] == ';' || p[i] == '=' ||
+				p[i] == '%' && i+2 < l && hex[p[i+1]] != 16 && hex[p[i+2]] != 16
 		default:
 			// noop
 		}
@@ -104,7 +132,7 @@ func bufEscape(dst, p []byte, mode mode) []byte {
 			n++
 		} else if p[i] == ' ' {
 			switch mode {
-			case modePath, modeURIComponent:
+			case modePath, modeURIComponent, modeMailto, modeFullPath, modeUserinfo:
 				dst = append(dst, "%20"...)
 				n += 3
 			default:
@@ -127,32 +155,24 @@ func bufEscape(dst, p []byte, mode mode) []byte {
 
 // Unescape p do dst according mode.
 func bufUnescape(dst, p []byte, mode mode) []byte {
-	_ = mode
 	o, l, sp := len(dst), len(p), samePtr(dst, p)
 	n := l
-	if l < 3 {
-		dst = append(dst, p...)
-		if sp {
-			copy(dst, dst[o:])
-			return dst[:l]
-		}
-		return dst
-	}
-	_ = p[l-1]
 	for i := 0; i < l; i++ {
 		switch p[i] {
 		case '%':
 			if i+2 < l {
 				x2 := hex[p[i+2]]
 				x1 := hex[p[i+1]]
-				if x1 != 16 || x2 != 16 {
+				if x1 != 16 && x2 != 16 {
 					dst = append(dst, x1<<4|x2)
 					i += 2
 					n -= 2
+					continue
 				}
 			}
+			dst = append(dst, '%')
 		case '+':
-			if mode != modePath {
+			if mode != modePath && mode != modeMailto {
 				dst = append(dst, ' ')
 			} else {
 				dst = append(dst, '+')
@@ -163,8 +183,9 @@ func bufUnescape(dst, p []byte, mode mode) []byte {
 	}
 	if sp {
 		copy(dst, dst[o:])
+		return dst[:n]
 	}
-	return dst[:n]
+	return dst[:o+n]
 }
 
 func vecEscape(vec *Vector, p []byte, mode mode) []byte {
@@ -179,8 +200,8 @@ func vecEscape(vec *Vector, p []byte, mode mode) []byte {
 }
 
 func vecUnescape(vec *Vector, p []byte, mode mode) []byte {
-	_, _ = vec, mode
-	return unescape(p)
+	_ = vec
+	return unescape(p, mode)
 }
 
 // Check if a and b has the same pointers. Needs for in-place escape/unescape.
@@ -118,6 +118,60 @@ func TestUnescape(t *testing.T) {
 	}
 }
 
+func TestUnescapeInvalid(t *testing.T) {
+	// Invalid and truncated escape sequences keeps as is.
+	ustages := []struct {
+		raw, query, path string
+	}{
+		{"%", "%", "%"},
+		{"%4", "%4", "%4"},
+		{"a%2", "a%2", "a%2"},
+		{"%zz", "%zz", "%zz"},
+		{"%4g1", "%4g1", "%4g1"},
+		{"%g41", "%g41", "%g41"},
+		{"%%41", "%A", "%A"},
+		{"%41%", "A%", "A%"},
+		{"100%25", "100%", "100%"},
+		{"100%", "100%", "100%"},
+		{"a+b%2B", "a b+", "a+b+"},
+	}
+	for i, stage := range ustages {
+		t.Run("query/"+strconv.Itoa(i), func(t *testing.T) {
+			var buf []byte
+			buf = QueryUnescape(buf[:0], byteconv.S2B(stage.raw))
+			if r := byteconv.B2S(buf); r != stage.query {
+				t.Errorf("unescape mismatch:\n\tneed '%s'\n\tgot  '%s'", stage.query, r)
+			}
+		})
+		t.Run("path/"+strconv.Itoa(i), func(t *testing.T) {
+			var buf []byte
+			buf = PathUnescape(buf[:0], byteconv.S2B(stage.raw))
+			if r := byteconv.B2S(buf); r != stage.path {
+				t.Errorf("unescape mismatch:\n\tneed '%s'\n\tgot  '%s'", stage.path, r)
+			}
+		})
+		t.Run("append/"+strconv.Itoa(i), func(t *testing.T) {
+			buf := []byte("prefix:")
+			buf = QueryUnescape(buf, byteconv.S2B(stage.raw))
+			if r := byteconv.B2S(buf); r != "prefix:"+stage.query {
+				t.Errorf("unescape mismatch:\n\tneed '%s'\n\tgot  '%s'", "prefix:"+stage.query, r)
+			}
+		})
+		t.Run("inplace/"+strconv.Itoa(i), func(t *testing.T) {
+			buf := []byte(stage.raw)
+			buf = QueryUnescape(buf[:0], buf)
+			if r := byteconv.B2S(buf); r != stage.query {
+				t.Errorf("unescape mismatch:\n\tneed '%s'\n\tgot  '%s'", stage.query, r)
+			}
+			buf = append(buf[:0], stage.raw...)
+			buf = unescape(buf, modePath)
+			if r := byteconv.B2S(buf); r != stage.path {
+				t.Errorf("unescape mismatch:\n\tneed '%s'\n\tgot  '%s'", stage.path, r)
+			}
+		})
+	}
+}
+
 func BenchmarkEscape(b *testing.B) {
 	for i, stage := range stages {
 		b.Run("query/"+strconv.Itoa(i), func(b *testing.B) {
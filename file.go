@@ -0,0 +1,181 @@
+package urlvector
+
+import (
+	"bytes"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+// PathStyle describes style of local file path.
+type PathStyle int
+
+const (
+	// PathStylePOSIX is a slash separated path (/home/user/file).
+	PathStylePOSIX PathStyle = iota
+	// PathStyleWindows is a backslash separated path with optional drive letter (C:\Users\file) or UNC host
+	// (\\server\share\file).
+	PathStyleWindows
+)
+
+var (
+	bWinSep     = []byte("/\\")
+	bWinLong    = []byte(`\\?\`)
+	bWinLongUNC = []byte(`\\?\UNC\`)
+)
+
+// FilePathBytes converts file URL to local path of given style.
+//
+// Path will be unescaped, non-empty host converts to UNC prefix (\\host for Windows style and //host for POSIX).
+// Drive letter path (/C:/x) converts to C:\x in Windows style and keeps as is in POSIX.
+// Result uses internal buffer and is valid until vector reset.
+func (vec *Vector) FilePathBytes(style PathStyle) []byte {
+	path := vec.PathBytes()
+	offset := vec.BufLen()
+	host := vec.HostnameBytes()
+	if len(host) > 0 {
+		vec.Bufferize(bSlashes)
+		vec.Bufferize(host)
+	}
+	o := vec.BufLen()
+	vec.Bufferize(path)
+	vec.SetBit(flagBufMod, true)
+	buf := vec.Buf()
+	if style != PathStyleWindows {
+		return buf[offset:]
+	}
+	if len(host) == 0 && driveOffset(buf[o:]) == 1 {
+		// Drop the slash before drive letter.
+		copy(buf[o:], buf[o+1:])
+		buf = buf[:len(buf)-1]
+		vec.BufReplaceWith(buf)
+	}
+	for i := offset; i < len(buf); i++ {
+		if buf[i] == '/' {
+			buf[i] = '\\'
+		}
+	}
+	return buf[offset:]
+}
+
+// FilePathString converts file URL to local path of given style.
+func (vec *Vector) FilePathString(style PathStyle) string {
+	return byteconv.B2S(vec.FilePathBytes(style))
+}
+
+// SetFilePathBytes replaces scheme, host and path with file URL built from local path of given style.
+//
+// Path segments will be escaped, UNC host (\\server\share or //server/share) moves to the host part.
+// Relative paths become absolute since file URL can't contain relative path.
+func (vec *Vector) SetFilePathBytes(path []byte, style PathStyle) *Vector {
+	var (
+		host []byte
+		unc  bool
+	)
+	sep := bSlash
+	if style == PathStyleWindows {
+		sep = bWinSep
+		// Long path prefix doesn't make sense in URL.
+		if bytes.HasPrefix(path, bWinLongUNC) {
+			path, unc = path[len(bWinLongUNC):], true
+		} else if bytes.HasPrefix(path, bWinLong) {
+			path = path[len(bWinLong):]
+		}
+	}
+	if !unc && len(path) > 2 && isFileSep(path[0], style) && isFileSep(path[1], style) && !isFileSep(path[2], style) {
+		path, unc = path[2:], true
+	}
+	if unc {
+		i := bytealg.IndexAnyAtBytes(path, sep, 0)
+		if i < 0 {
+			i = len(path)
+		}
+		host, path = path[:i], path[i:]
+	}
+
+	offset := vec.BufLen()
+	for len(path) > 0 && isFileSep(path[0], style) {
+		path = path[1:]
+	}
+	for {
+		i := bytealg.IndexAnyAtBytes(path, sep, 0)
+		if i < 0 {
+			i = len(path)
+		}
+		vec.Bufferize(bSlash)
+		vecEscape(vec, path[:i], modePath)
+		if i == len(path) {
+			break
+		}
+		path = path[i+1:]
+	}
+	vec.resetOpaque()
+	vec.setTail(vec.Path(), offset)
+	// Path keeps escaped to assemble valid URL, PathBytes() and FilePathBytes() unescape it.
+	vec.Path().Value().SetBit(flagEscape, bytealg.IndexByteAtBytes(vec.Buf()[offset:], '%', 0) >= 0)
+
+	vec.SetBit(flagOpaque, false)
+	vec.SetBit(flagFile, true)
+	vec.set(vec.Scheme(), bFile)
+	vec.set(vec.Auth(), nil)
+	vec.set(vec.Username(), nil)
+	vec.set(vec.Password(), nil)
+	vec.set(vec.Host(), host)
+	vec.set(vec.Hostname(), host)
+	return vec.set(vec.getByIdx(idxPort), nil)
+}
+
+// SetFilePathString replaces scheme, host and path with file URL built from local path of given style.
+func (vec *Vector) SetFilePathString(path string, style PathStyle) *Vector {
+	return vec.SetFilePathBytes(byteconv.S2B(path), style)
+}
+
+// Normalize parsed file path: replace backslashes, fix legacy drive letter (C|) and add leading slash before drive
+// letter.
+func (vec *Vector) normFilePath(path *vector.Node) {
+	p, esc := path.Value().RawBytes(), path.Value().CheckBit(flagEscape)
+	drive := driveOffset(p)
+	if bytealg.IndexByteAtBytes(p, '\\', 0) < 0 && (drive < 0 || (drive == 1 && p[2] == ':')) {
+		return
+	}
+	offset := vec.BufLen()
+	if drive == 0 {
+		vec.Bufferize(bSlash)
+	}
+	vec.Bufferize(p)
+	buf := vec.Buf()
+	for i := offset; i < len(buf); i++ {
+		if buf[i] == '\\' {
+			buf[i] = '/'
+		}
+	}
+	if drive >= 0 {
+		buf[offset+2] = ':'
+	}
+	vec.setTail(path, offset)
+	path.Value().SetBit(flagEscape, esc)
+}
+
+// Get offset of drive letter in path (0 for C:/x, 1 for /C:/x) or -1 if path doesn't start with drive letter.
+func driveOffset(p []byte) int {
+	for i := 0; i < 2 && i+2 <= len(p); i++ {
+		if i == 1 && p[0] != '/' && p[0] != '\\' {
+			break
+		}
+		if isDriveLetter(p[i:i+2]) && (len(p) == i+2 || p[i+2] == '/' || p[i+2] == '\\') {
+			return i
+		}
+	}
+	return -1
+}
+
+// Check if p is a Windows drive letter (C: or legacy C|).
+func isDriveLetter(p []byte) bool {
+	return len(p) == 2 && (p[0]|0x20 >= 'a' && p[0]|0x20 <= 'z') && (p[1] == ':' || p[1] == '|')
+}
+
+// Check if c is a path separator in given style.
+func isFileSep(c byte, style PathStyle) bool {
+	return c == '/' || (style == PathStyleWindows && c == '\\')
+}
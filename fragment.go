@@ -0,0 +1,333 @@
+package urlvector
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+var (
+	// Fragment directive delimiter, see https://wicg.github.io/scroll-to-text-fragment/#the-fragment-directive
+	bFragDirective = []byte(":~:")
+	bTextDirective = []byte("text=")
+	bHashBang      = []byte("!")
+	bNPT           = []byte("npt")
+	bDash          = []byte("-")
+	bEscapeChars   = []byte("%+")
+)
+
+// Fragment returns fragment node with hash parsed to structured data.
+//
+// Depending on fragment contents the node may contain the following children:
+// * path - hash route path (`#/orders/5` or `#!/orders/5`) or plain anchor (`#section`)
+// * query - hash route query params, parsed the same way as Query()
+// * text - array of text fragment directives (`#:~:text=prefix-,start,end,-suffix`)
+// * t, xywh, track, id - media fragment dimensions (`#t=10,20`, `#xywh=percent:25,25,50,50`)
+//
+// Note that fragment will be parsed by first attempt to access it.
+func (vec *Vector) Fragment() *vector.Node {
+	fragment := vec.getByIdx(idxFragment)
+	if !vec.CheckBit(flagFragParsed) {
+		vec.SetBit(flagFragParsed, true)
+		vec.parseFragment(fragment)
+		fragment = vec.getByIdx(idxFragment)
+	}
+	vec.rebase(fragment)
+	return fragment
+}
+
+// HashPath returns hash route path node.
+func (vec *Vector) HashPath() *vector.Node {
+	return vec.Fragment().Get("path")
+}
+
+// HashQuery returns hash route query node.
+func (vec *Vector) HashQuery() *vector.Node {
+	return vec.Fragment().Get("query")
+}
+
+// HashText returns array of text fragment directives.
+//
+// Each directive is an object with optional keys prefix, start, end and suffix.
+func (vec *Vector) HashText() *vector.Node {
+	return vec.Fragment().Get("text")
+}
+
+// HashTime returns media fragment temporal dimension node (object with keys unit, start and end).
+func (vec *Vector) HashTime() *vector.Node {
+	return vec.Fragment().Get("t")
+}
+
+// HashTimeRange returns media fragment temporal range in seconds.
+//
+// Only npt format is supported. If end of the range omitted, -1 will return instead.
+func (vec *Vector) HashTimeRange() (start, end float64, ok bool) {
+	t := vec.HashTime()
+	if t.Type() != vector.TypeObject || !bytes.Equal(t.Get("unit").Bytes(), bNPT) {
+		return
+	}
+	end = -1
+	if raw := t.Get("start").Bytes(); len(raw) > 0 {
+		if start, ok = parseNPT(raw); !ok {
+			return
+		}
+	}
+	if raw := t.Get("end").Bytes(); len(raw) > 0 {
+		if end, ok = parseNPT(raw); !ok {
+			return
+		}
+	}
+	ok = true
+	return
+}
+
+// HashSpace returns media fragment spatial dimension node (object with keys unit, x, y, w and h).
+func (vec *Vector) HashSpace() *vector.Node {
+	return vec.Fragment().Get("xywh")
+}
+
+// Parse hash to fragment children.
+func (vec *Vector) parseFragment(fragment *vector.Node) {
+	// Unescaped values and copied hash route params fit twice length of the hash.
+	vec.reserve(vec.HashBytes(), 2*len(vec.HashBytes()))
+	origin := bytealg.TrimLeft(vec.HashBytes(), bHash)
+	if len(origin) == 0 {
+		return
+	}
+	fragment.SetOffset(vec.Index.Len(2))
+
+	// Split fragment to regular part and fragment directive.
+	if pos := bytes.Index(origin, bFragDirective); pos >= 0 {
+		vec.parseFragmentDirective(fragment, origin, pos+len(bFragDirective))
+		origin = origin[:pos]
+	}
+	if len(origin) == 0 {
+		vec.ReleaseNode(fragment.Index(), fragment)
+		return
+	}
+
+	if isMediaFragment(origin) {
+		vec.parseMediaFragment(fragment, origin)
+	} else {
+		vec.parseHashRoute(fragment, origin)
+	}
+	vec.ReleaseNode(fragment.Index(), fragment)
+}
+
+// Parse hash route (path and query).
+func (vec *Vector) parseHashRoute(fragment *vector.Node, origin []byte) {
+	offset := 0
+	if bytes.HasPrefix(origin, bHashBang) {
+		offset++
+	}
+	posQM := bytealg.IndexByteAtBytes(origin, '?', offset)
+	if posQM < 0 {
+		posQM = len(origin)
+	}
+	if posQM > offset {
+		path, i := vec.AcquireChildWithType(fragment, 2, vector.TypeString)
+		path.Key().Init(bKeys, offsetPath, lenPath)
+		vec.initUnescaped(path, origin, offset, posQM-offset, modePath)
+		vec.ReleaseNode(i, path)
+	}
+	if posQM < len(origin)-1 {
+		query, i := vec.AcquireChildWithType(fragment, 2, vector.TypeObject)
+		query.Key().Init(bKeys, offsetQuery, lenQuery)
+		vec.initPtr(query.Value(), origin, posQM, len(origin)-posQM)
+		vec.ReleaseNode(i, query)
+		// Params of copied source unescapes in-place, so copy them to the buffer to keep the hash untouched.
+		params := origin[posQM+1:]
+		if vec.CheckBit(flagCopy) && bytealg.IndexAnyAtBytes(params, bEscapeChars, 0) >= 0 {
+			o := vec.BufLen()
+			vec.Bufferize(params)
+			vec.SetBit(flagBufMod, true)
+			params = vec.Buf()[o:]
+		}
+		vec.parseParams(query, params, 3)
+	}
+}
+
+// Parse fragment directive (text fragments).
+func (vec *Vector) parseFragmentDirective(fragment *vector.Node, origin []byte, offset int) {
+	var text *vector.Node
+	n := len(origin)
+	for offset < n {
+		i := bytealg.IndexByteAtBytes(origin, '&', offset)
+		if i < 0 {
+			i = n
+		}
+		if d := origin[offset:i]; bytes.HasPrefix(d, bTextDirective) && len(d) > len(bTextDirective) {
+			if text == nil {
+				text, _ = vec.AcquireChildWithType(fragment, 2, vector.TypeArray)
+				text.SetOffset(vec.Index.Len(3))
+				text.Key().Init(bKeys, offsetText, lenText)
+			}
+			vec.parseTextDirective(text, origin, offset+len(bTextDirective), i)
+			vec.ReleaseNode(text.Index(), text)
+		}
+		offset = i + 1
+	}
+}
+
+// Parse single text directive `[prefix-,]start[,end][,-suffix]` between offset and limit of origin.
+func (vec *Vector) parseTextDirective(text *vector.Node, origin []byte, offset, limit int) {
+	node, idx := vec.AcquireChildWithType(text, 3, vector.TypeObject)
+	node.SetOffset(vec.Index.Len(4))
+	var c int
+	var start bool
+	for offset < limit {
+		i := bytealg.IndexByteAtBytes(origin[:limit], ',', offset)
+		if i < 0 {
+			i = limit
+		}
+		part := origin[offset:i]
+		koff, klen, poff, plen := offsetStart, lenStart, offset, len(part)
+		switch {
+		case c == 0 && i < limit && bytes.HasSuffix(part, bDash):
+			koff, klen, plen = offsetPrefix, lenPrefix, plen-1
+		case i == limit && bytes.HasPrefix(part, bDash) && c > 0:
+			koff, klen, poff, plen = offsetSuffix, lenSuffix, poff+1, plen-1
+		case start:
+			koff, klen = offsetEnd, lenEnd
+		default:
+			start = true
+		}
+		child, j := vec.AcquireChildWithType(node, 4, vector.TypeString)
+		child.Key().Init(bKeys, koff, klen)
+		vec.initUnescaped(child, origin, poff, plen, modePath)
+		vec.ReleaseNode(j, child)
+		c++
+		offset = i + 1
+	}
+	vec.ReleaseNode(idx, node)
+}
+
+// Parse media fragment (https://www.w3.org/TR/media-frags/) name-value pairs.
+func (vec *Vector) parseMediaFragment(fragment *vector.Node, origin []byte) {
+	var offset int
+	n := len(origin)
+	for offset < n {
+		i := bytealg.IndexByteAtBytes(origin, '&', offset)
+		if i < 0 {
+			i = n
+		}
+		j := bytealg.IndexByteAtBytes(origin[:i], '=', offset)
+		if j < 0 {
+			offset = i + 1
+			continue
+		}
+		name, voff := origin[offset:j], j+1
+		switch byteconv.B2S(name) {
+		case "t":
+			vec.parseMediaDim(fragment, origin, voff, i, &dimTemporal)
+		case "xywh":
+			vec.parseMediaDim(fragment, origin, voff, i, &dimSpatial)
+		case "track", "id":
+			node, k := vec.AcquireChildWithType(fragment, 2, vector.TypeString)
+			if name[0] == 't' {
+				node.Key().Init(bKeys, offsetTrack, lenTrack)
+			} else {
+				node.Key().Init(bKeys, offsetID, lenID)
+			}
+			vec.initUnescaped(node, origin, voff, i-voff, modePath)
+			vec.ReleaseNode(k, node)
+		}
+		offset = i + 1
+	}
+}
+
+// Media fragment dimension: key and default unit positions in bKeys.
+type mediaDim struct {
+	koff, klen, uoff, ulen int
+	spatial                bool
+}
+
+var (
+	dimTemporal = mediaDim{koff: offsetText, klen: 1, uoff: offsetNPT, ulen: lenNPT}
+	dimSpatial  = mediaDim{koff: offsetXYWH, klen: lenXYWH, uoff: offsetPixel, ulen: lenPixel, spatial: true}
+)
+
+// Parse media fragment dimension `[unit:]value[,value...]` between offset and limit of origin.
+//
+// Temporal dimension produces start and end children, spatial dimension - x, y, w and h.
+func (vec *Vector) parseMediaDim(fragment *vector.Node, origin []byte, offset, limit int, dim *mediaDim) {
+	node, idx := vec.AcquireChildWithType(fragment, 2, vector.TypeObject)
+	node.SetOffset(vec.Index.Len(3))
+	node.Key().Init(bKeys, dim.koff, dim.klen)
+
+	unit, iu := vec.AcquireChildWithType(node, 3, vector.TypeString)
+	unit.Key().Init(bKeys, offsetUnit, lenUnit)
+	unit.Value().Init(bKeys, dim.uoff, dim.ulen)
+	if i := bytealg.IndexByteAtBytes(origin[:limit], ':', offset); i >= 0 {
+		// Note that time formats (npt, smpte) may contain colons in values, so consider only prefixes without digits.
+		if prefix := origin[offset:i]; len(prefix) > 0 && (prefix[0] < '0' || prefix[0] > '9') {
+			vec.initPtr(unit.Value(), origin, offset, len(prefix))
+			offset = i + 1
+		}
+	}
+	vec.ReleaseNode(iu, unit)
+
+	var c int
+	for offset <= limit {
+		i := bytealg.IndexByteAtBytes(origin[:limit], ',', offset)
+		if i < 0 {
+			i = limit
+		}
+		typ, koff, klen := vector.TypeString, offsetStart, lenStart
+		if dim.spatial {
+			if c > 3 {
+				break
+			}
+			typ, koff, klen = vector.TypeNumber, offsetXYWH+c, 1
+		} else if c > 0 {
+			koff, klen = offsetEnd, lenEnd
+		}
+		if i > offset || dim.spatial {
+			child, j := vec.AcquireChildWithType(node, 3, typ)
+			child.Key().Init(bKeys, koff, klen)
+			vec.initPtr(child.Value(), origin, offset, i-offset)
+			vec.ReleaseNode(j, child)
+		}
+		c++
+		offset = i + 1
+	}
+	vec.ReleaseNode(idx, node)
+}
+
+// Check if fragment is a media fragment, i.e. starts with one of the known dimension names.
+func isMediaFragment(p []byte) bool {
+	i := bytealg.IndexByteAtBytes(p, '=', 0)
+	if i < 0 {
+		return false
+	}
+	switch byteconv.B2S(p[:i]) {
+	case "t", "xywh", "track", "id":
+		return true
+	}
+	return false
+}
+
+// Convert npt time (`[[hh:]mm:]ss[.fraction]`) to seconds.
+func parseNPT(p []byte) (float64, bool) {
+	var r float64
+	for {
+		i := bytealg.IndexByteAtBytes(p, ':', 0)
+		if i < 0 {
+			break
+		}
+		v, err := strconv.ParseUint(byteconv.B2S(p[:i]), 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		r = (r + float64(v)) * 60
+		p = p[i+1:]
+	}
+	v, err := strconv.ParseFloat(byteconv.B2S(p), 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return r + v, true
+}
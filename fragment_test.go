@@ -0,0 +1,172 @@
+package urlvector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koykov/vector"
+)
+
+func TestFragment(t *testing.T) {
+	t.Run("route", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("https://app.example.com/#/orders/5?tab=items&ids[]=1&ids[]=2")
+		if p := vec.HashPath().String(); p != "/orders/5" {
+			t.Error("hash path mismatch", "need", "/orders/5", "got", p)
+		}
+		if tab := vec.HashQuery().GetString("tab"); tab != "items" {
+			t.Error("hash query mismatch", "need", "items", "got", tab)
+		}
+		if l := vec.HashQuery().Get("ids[]").Limit(); l != 2 {
+			t.Error("hash query array length mismatch", "need", 2, "got", l)
+		}
+
+		vec.Reset()
+		_ = vec.ParseString("https://app.example.com/#/search?q=foo+bar%21")
+		if q := vec.HashQuery().GetString("q"); q != "foo bar!" {
+			t.Error("hash query mismatch", "need", "foo bar!", "got", q)
+		}
+		if s := vec.String(); s != "https://app.example.com/#/search?q=foo+bar%21" {
+			t.Error("url assembly failed", "got", s)
+		}
+	})
+
+	t.Run("hashbang", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("https://example.com/#!/users/john%20doe")
+		if p := vec.HashPath().String(); p != "/users/john doe" {
+			t.Error("hash path mismatch", "need", "/users/john doe", "got", p)
+		}
+		if h := vec.HashString(); h != "#!/users/john%20doe" {
+			t.Error("hash mismatch", "need", "#!/users/john%20doe", "got", h)
+		}
+	})
+
+	t.Run("anchor", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("https://example.com/doc#section-2")
+		if p := vec.HashPath().String(); p != "section-2" {
+			t.Error("hash path mismatch", "need", "section-2", "got", p)
+		}
+		if q := vec.HashQuery(); q.Limit() != 0 {
+			t.Error("unexpected hash query")
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("https://example.com/#intro:~:text=an%20example-,text,fragment,-here&unknown=1&text=single")
+		if p := vec.HashPath().String(); p != "intro" {
+			t.Error("hash path mismatch", "need", "intro", "got", p)
+		}
+		text := vec.HashText()
+		if text.Limit() != 2 {
+			t.Fatal("text directives count mismatch", "need", 2, "got", text.Limit())
+		}
+		d0 := text.At(0)
+		for k, v := range map[string]string{"prefix": "an example", "start": "text", "end": "fragment", "suffix": "here"} {
+			if r := d0.GetString(k); r != v {
+				t.Error("text directive mismatch", k, "need", v, "got", r)
+			}
+		}
+		if r := text.At(1).GetString("start"); r != "single" {
+			t.Error("text directive mismatch", "need", "single", "got", r)
+		}
+	})
+
+	t.Run("media", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("https://example.com/video.mp4#t=npt:1:10,90.5&xywh=percent:25,25,50,50&track=audio")
+		start, end, ok := vec.HashTimeRange()
+		if !ok || start != 70 || end != 90.5 {
+			t.Error("time range mismatch", "need", 70, 90.5, "got", start, end, ok)
+		}
+		space := vec.HashSpace()
+		if u := space.GetString("unit"); u != "percent" {
+			t.Error("space unit mismatch", "need", "percent", "got", u)
+		}
+		if w := space.GetString("w"); w != "50" {
+			t.Error("space width mismatch", "need", "50", "got", w)
+		}
+		if tr := vec.Fragment().GetString("track"); tr != "audio" {
+			t.Error("track mismatch", "need", "audio", "got", tr)
+		}
+
+		vec.Reset()
+		_ = vec.ParseString("https://example.com/video.mp4#t=10")
+		start, end, ok = vec.HashTimeRange()
+		if !ok || start != 10 || end != -1 {
+			t.Error("time range mismatch", "need", 10, -1, "got", start, end, ok)
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("https://example.com/#/a?x=1")
+		_ = vec.HashPath()
+		vec.SetHashString("#/b?y=2")
+		if p := vec.HashPath().String(); p != "/b" {
+			t.Error("hash path mismatch", "need", "/b", "got", p)
+		}
+		if y := vec.HashQuery().GetString("y"); y != "2" {
+			t.Error("hash query mismatch", "need", "2", "got", y)
+		}
+		vec.SetQueryString("?z=3")
+		if p := vec.HashPath().String(); p != "/b" {
+			t.Error("hash path mismatch", "need", "/b", "got", p)
+		}
+	})
+
+	t.Run("buffer growth", func(t *testing.T) {
+		const hash = "#/a%20b?x=%31&y=2:~:text=c%20d"
+		for _, prep := range []func(){
+			func() { _ = vec.ParseString("https://example.com/" + hash) },
+			func() { _ = vec.ParseCopyString("https://example.com/" + hash) },
+			func() { _ = vec.ParseString("https://example.com/"); vec.SetHashString(hash) },
+		} {
+			vec.Reset()
+			prep()
+			_ = vec.Fragment()
+			// Reallocate the buffer after parsing.
+			vec.SetPathString(strings.Repeat("p", 4096))
+			if p := vec.HashPath().String(); p != "/a b" {
+				t.Error("hash path mismatch", "need", "/a b", "got", p)
+			}
+			if x := vec.HashQuery().GetString("x"); x != "1" {
+				t.Error("hash query mismatch", "need", "1", "got", x)
+			}
+			if s := vec.HashText().At(0).Get("start").String(); s != "c d" {
+				t.Error("text directive mismatch", "need", "c d", "got", s)
+			}
+			testAddr(t, vec, vec.Fragment())
+			if h := vec.HashString(); h != hash {
+				t.Error("hash mismatch", "need", hash, "got", h)
+			}
+		}
+	})
+}
+
+// Check that keys and values of node's children point to the source, the buffer or predefined keys.
+func testAddr(t *testing.T, vec *Vector, node *vector.Node) {
+	node.Each(func(_ int, child *vector.Node) {
+		for _, p := range []*vector.Byteptr{child.Key(), child.Value()} {
+			if raw := p.RawBytes(); len(raw) > 0 && ptrOffset(vec.Src(), raw) < 0 && ptrOffset(vec.Buf(), raw) < 0 &&
+				ptrOffset(bKeys, raw) < 0 {
+				t.Error("node points to released memory", "key", child.Key().String(), "value", p.String())
+			}
+		}
+		testAddr(t, vec, child)
+	})
+}
+
+func BenchmarkFragment(b *testing.B) {
+	src := "https://app.example.com/#/orders/5?tab=items&sort=desc"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vec.Reset()
+		_ = vec.ParseString(src)
+		if tab := vec.HashQuery().GetString("tab"); tab != "items" {
+			b.Error("hash query mismatch", "need", "items", "got", tab)
+		}
+	}
+}
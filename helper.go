@@ -6,25 +6,28 @@ import (
 	"github.com/koykov/vector"
 )
 
-type Helper struct{}
+type Helper struct {
+	// Owner vector to unescape values to.
+	vec *Vector
+}
 
 var helper = Helper{}
 
+// Indirect returns bytes of p. Escaped path unescapes to the owner vector's buffer once per modification, see
+// Vector.PathBytes().
 func (h Helper) Indirect(p *vector.Byteptr) []byte {
-	b := p.RawBytes()
-	if p.CheckBit(flagEscape) {
-		p.SetBit(flagEscape, false)
-		b = unescape(b)
-		p.SetLen(len(b))
+	if p.CheckBit(flagEscape) && h.vec != nil {
+		return h.vec.unescapePath(p)
 	}
-	return b
+	return p.RawBytes()
 }
 
-func (h Helper) Beautify(_ io.Writer, _ *vector.Node) error {
-	return nil
+// Beautify writes indented JSON representation of the node.
+func (h Helper) Beautify(w io.Writer, node *vector.Node) error {
+	return marshalNode(w, node, true)
 }
 
+// Marshal writes JSON representation of the node.
 func (h Helper) Marshal(w io.Writer, node *vector.Node) error {
-	_, err := w.Write(node.Bytes())
-	return err
+	return marshalNode(w, node, false)
 }
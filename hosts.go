@@ -0,0 +1,80 @@
+package urlvector
+
+import (
+	"github.com/koykov/bytealg"
+	"github.com/koykov/vector"
+)
+
+var bAuthorityEnd = []byte("/?#")
+
+// Hosts returns array of hosts, each host is an object with hostname and port children.
+//
+// Multiple comma separated hosts (mongodb://h1:27017,h2:27017/db) parses only with OptionDSN option. In other cases
+// array contains the only host.
+// Note that hosts will be parsed by first attempt to access it.
+func (vec *Vector) Hosts() *vector.Node {
+	hosts := vec.getByIdx(idxHosts)
+	if !vec.CheckBit(flagHostsParsed) {
+		vec.SetBit(flagHostsParsed, true)
+		vec.parseHosts(hosts)
+		hosts = vec.getByIdx(idxHosts)
+	}
+	vec.rebase(hosts)
+	return hosts
+}
+
+// Parse host to array of hostname/port pairs.
+func (vec *Vector) parseHosts(hosts *vector.Node) {
+	origin := vec.HostBytes()
+	if len(origin) == 0 {
+		return
+	}
+	hosts.SetOffset(vec.Index.Len(2))
+	for offset := 0; offset < len(origin); {
+		i := len(origin)
+		if vec.opts&OptionDSN != 0 {
+			if i = bytealg.IndexByteAtBytes(origin, ',', offset); i < 0 {
+				i = len(origin)
+			}
+		}
+		if i > offset {
+			host, j := vec.AcquireChildWithType(hosts, 2, vector.TypeObject)
+			host.SetOffset(vec.Index.Len(3))
+			hostname, k := vec.AcquireChildWithType(host, 3, vector.TypeString)
+			hostname.Key().Init(bKeys, offsetHostname, lenHostname)
+			port, l := vec.AcquireChildWithType(host, 3, vector.TypeNumber)
+			port.Key().Init(bKeys, offsetPort, lenPort)
+			if posCol := portColIdx(origin, offset, i); posCol >= 0 {
+				vec.initPtr(hostname.Value(), origin, offset, posCol-offset)
+				vec.initPtr(port.Value(), origin, posCol+1, i-posCol-1)
+			} else {
+				vec.initPtr(hostname.Value(), origin, offset, i-offset)
+			}
+			vec.ReleaseNode(k, hostname)
+			vec.ReleaseNode(l, port)
+			vec.ReleaseNode(j, host)
+		}
+		offset = i + 1
+	}
+	vec.ReleaseNode(hosts.Index(), hosts)
+}
+
+// Drop parsed hosts to parse it again by next access.
+func (vec *Vector) resetHosts() {
+	vec.touch()
+	vec.SetBit(flagHostsParsed, false)
+	vec.GetByIdx(idxHosts).SetLimit(0)
+}
+
+// Get position of port colon in p[lo:hi] or -1 if port is absent. Colons inside IPv6 brackets are ignored.
+func portColIdx(p []byte, lo, hi int) int {
+	for i := hi - 1; i >= lo; i-- {
+		switch p[i] {
+		case ':':
+			return i
+		case ']':
+			return -1
+		}
+	}
+	return -1
+}
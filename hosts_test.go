@@ -0,0 +1,106 @@
+package urlvector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHosts(t *testing.T) {
+	t.Run("dsn", func(t *testing.T) {
+		vec.Reset()
+		vec.SetOptions(OptionDSN)
+		src := "mongodb://u:p@h1:27017,h2:27017,[::1]:27018/db?replicaSet=rs"
+		if err := vec.ParseString(src); err != nil {
+			t.Fatal(err)
+		}
+		if u := vec.UsernameString(); u != "u" {
+			t.Error("username mismatch", "need", "u", "got", u)
+		}
+		if h := vec.HostnameString(); h != "h1" {
+			t.Error("hostname mismatch", "need", "h1", "got", h)
+		}
+		if p := vec.Port(); p != 27017 {
+			t.Error("port mismatch", "need", 27017, "got", p)
+		}
+		if p := vec.PathString(); p != "/db" {
+			t.Error("path mismatch", "need", "/db", "got", p)
+		}
+		hosts := vec.Hosts()
+		if hosts.Limit() != 3 {
+			t.Fatal("hosts count mismatch", "need", 3, "got", hosts.Limit())
+		}
+		for i, need := range [][2]string{{"h1", "27017"}, {"h2", "27017"}, {"[::1]", "27018"}} {
+			host := hosts.At(i)
+			if h := host.GetString("hostname"); h != need[0] {
+				t.Error("hostname mismatch", "need", need[0], "got", h)
+			}
+			if p := host.GetString("port"); p != need[1] {
+				t.Error("port mismatch", "need", need[1], "got", p)
+			}
+		}
+		if s := vec.String(); s != src {
+			t.Error("url assembly failed", "need", src, "got", s)
+		}
+
+		vec.Reset()
+		vec.SetOptions(OptionDSN)
+		_ = vec.ParseString("postgres://h1,h2/db")
+		if l := vec.Hosts().Limit(); l != 2 {
+			t.Error("hosts count mismatch", "need", 2, "got", l)
+		}
+		vec.SetHostString("h3:5432,h4")
+		if s := vec.String(); s != "postgres://h3:5432,h4/db" {
+			t.Error("url assembly failed", "got", s)
+		}
+		vec.SetHostnameString("h5")
+		if s := vec.String(); s != "postgres://h5:5432/db" || vec.HostString() != "h5:5432" || vec.Hosts().Limit() != 1 {
+			t.Error("url assembly failed", "got", s, vec.HostString())
+		}
+		vec.SetPort(5433)
+		if s := vec.String(); s != "postgres://h5:5433/db" || vec.HostString() != "h5:5433" {
+			t.Error("url assembly failed", "got", s, vec.HostString())
+		}
+	})
+
+	t.Run("single", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("https://example.com:8443/a,b")
+		hosts := vec.Hosts()
+		if hosts.Limit() != 1 {
+			t.Fatal("hosts count mismatch", "need", 1, "got", hosts.Limit())
+		}
+		if h := hosts.At(0).GetString("hostname"); h != "example.com" {
+			t.Error("hostname mismatch", "need", "example.com", "got", h)
+		}
+		if o := vec.Options(); o != 0 {
+			t.Error("options must be dropped by reset")
+		}
+	})
+
+	t.Run("buffer growth", func(t *testing.T) {
+		vec.Reset()
+		vec.SetOptions(OptionDSN)
+		_ = vec.ParseString("postgres://h1/db")
+		vec.SetHostString("h2:5432,h3:5433")
+		_ = vec.Hosts()
+		// Reallocate the buffer after parsing.
+		vec.SetHashString(strings.Repeat("h", 4096))
+		if p := vec.Hosts().At(1).GetString("port"); p != "5433" {
+			t.Error("port mismatch", "need", "5433", "got", p)
+		}
+		testAddr(t, vec, vec.Hosts())
+	})
+}
+
+func BenchmarkHosts(b *testing.B) {
+	src := "mongodb://u:p@h1:27017,h2:27017,h3:27017/db?replicaSet=rs"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vec.Reset()
+		vec.SetOptions(OptionDSN)
+		_ = vec.ParseString(src)
+		if l := vec.Hosts().Limit(); l != 3 {
+			b.Error("hosts count mismatch", "need", 3, "got", l)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Package httpx provides net/http integration of URL vector.
+package httpx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/koykov/urlvector"
+)
+
+type ctxKey struct{}
+
+// Middleware returns net/http middleware that acquires URL vector from the pool, parses request URL once and stores
+// the vector to the request context, see FromContext().
+//
+// Request URL reconstructs by urlvector.ParseRequest() considering given trust policy. Vector releases back to the
+// pool after handler returns, so it must not be used outside of handler lifetime (in spawned goroutines, etc).
+func Middleware(trust urlvector.TrustPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			vec := urlvector.Acquire()
+			defer urlvector.Release(vec)
+			if err := vec.ParseRequest(r, trust); err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, vec)))
+		})
+	}
+}
+
+// FromContext returns URL vector stored by Middleware or nil if context has no vector.
+func FromContext(ctx context.Context) *urlvector.Vector {
+	vec, _ := ctx.Value(ctxKey{}).(*urlvector.Vector)
+	return vec
+}
@@ -0,0 +1,51 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/koykov/urlvector"
+)
+
+func TestMiddleware(t *testing.T) {
+	var (
+		host, tab string
+		vec       *urlvector.Vector
+	)
+	h := Middleware(urlvector.TrustPolicy{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vec = FromContext(r.Context())
+		if vec == nil {
+			t.Fatal("vector not found in context")
+		}
+		host, tab = vec.HostnameString(), vec.Query().GetString("tab")
+	}))
+	r := httptest.NewRequest("GET", "http://example.com/orders?tab=items", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if host != "example.com" {
+		t.Error("host mismatch", "need", "example.com", "got", host)
+	}
+	if tab != "items" {
+		t.Error("query mismatch", "need", "items", "got", tab)
+	}
+	if vec.Len() != 0 {
+		t.Error("vector must be released after handler return")
+	}
+	if FromContext(r.Context()) != nil {
+		t.Error("unexpected vector in context")
+	}
+}
+
+func BenchmarkMiddleware(b *testing.B) {
+	h := Middleware(urlvector.TrustPolicy{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if vec := FromContext(r.Context()); vec == nil {
+			b.Error("vector not found in context")
+		}
+	}))
+	r := httptest.NewRequest("GET", "http://example.com/orders?tab=items", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,630 @@
+package urlvector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+var (
+	bJSONQuote  = []byte(`"`)
+	bJSONEscQ   = []byte(`\"`)
+	bJSONEscBS  = []byte(`\\`)
+	bJSONEscN   = []byte(`\n`)
+	bJSONEscR   = []byte(`\r`)
+	bJSONEscT   = []byte(`\t`)
+	bJSONEscU   = []byte(`\u00`)
+	bJSONObjO   = []byte("{")
+	bJSONObjC   = []byte("}")
+	bJSONArrO   = []byte("[")
+	bJSONArrC   = []byte("]")
+	bJSONColon  = []byte(":")
+	bJSONColonS = []byte(": ")
+	bJSONComma  = []byte(",")
+	bJSONNL     = []byte("\n")
+	bJSONIndent = []byte("\t")
+	bTrue       = []byte("true")
+	bFalse      = []byte("false")
+	bNull       = []byte("null")
+	bHexUp      = []byte(hexUp)
+
+	ErrBadJSON = errors.New("bad JSON representation of URL")
+
+	// All single byte values to write decoded bytes without allocation.
+	bChars = func() []byte {
+		b := make([]byte, 256)
+		for i := 0; i < len(b); i++ {
+			b[i] = byte(i)
+		}
+		return b
+	}()
+)
+
+// Beautify writes indented JSON representation of the URL to w.
+//
+// See Marshal() for the format.
+func (vec *Vector) Beautify(w io.Writer) error {
+	vec.actualize()
+	return vec.Vector.Beautify(w)
+}
+
+// Marshal writes JSON representation of the URL to w.
+//
+// URL marshals to object with keys scheme, username, password, host, port, path, query and hash, empty parts omits.
+// Multiple hosts of OptionDSN URL writes as hosts array of objects with host and port keys instead of host and port.
+// Path writes unescaped except sequences which don't decode to valid UTF-8 or encode reserved characters (%2F, %3F,
+// ...), query writes as object of parsed and unescaped params (params with [] suffix are arrays), hash writes without
+// leading #.
+func (vec *Vector) Marshal(w io.Writer) error {
+	vec.actualize()
+	return vec.Vector.Marshal(w)
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (vec *Vector) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	err := vec.Marshal(&buf)
+	return buf.Bytes(), err
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+//
+// Vector resets before parsing, zero vector (e.g. struct field) initializes automatically.
+func (vec *Vector) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, bNull) {
+		return nil
+	}
+	vec.ensureInit()
+	vec.Reset()
+	return vec.ParseJSON(data)
+}
+
+// ParseJSON builds URL from JSON representation produced by Marshal().
+//
+// Parts escapes as needed: username, password and path of URL with authority escapes keeping valid escape sequences
+// (Marshal() keeps reserved and undecodable ones), query keys and values escapes. Opaque path and hash keeps as is.
+// Query values may be strings, numbers, booleans or arrays of them.
+func (vec *Vector) ParseJSON(data []byte) error {
+	var u jsonURL
+	if err := json.Unmarshal(data, &u); err != nil {
+		return err
+	}
+	port, err := u.port()
+	if err != nil {
+		return err
+	}
+
+	buf := vec.tmp[:0]
+	opaque := len(u.Scheme) > 0 && len(u.Host) == 0 && len(u.Hosts) == 0 && len(u.Username) == 0 && len(u.Password) == 0 &&
+		len(port) == 0 && !strings.HasPrefix(u.Path, "/")
+	if len(u.Scheme) > 0 {
+		buf = append(buf, u.Scheme...)
+		buf = append(buf, ':')
+	}
+	if !opaque && (len(u.Scheme) > 0 || len(u.Host) > 0) {
+		buf = append(buf, bSlashes...)
+	}
+	if len(u.Username) > 0 || len(u.Password) > 0 {
+		buf = bufEscape(buf, byteconv.S2B(u.Username), modeUserinfo)
+		if len(u.Password) > 0 {
+			buf = append(buf, ':')
+			buf = bufEscape(buf, byteconv.S2B(u.Password), modeUserinfo)
+		}
+		buf = append(buf, '@')
+	}
+	if len(u.Hosts) > 0 {
+		// Multiple hosts requires DSN mode to parse them back.
+		vec.opts |= OptionDSN
+		for i := 0; i < len(u.Hosts); i++ {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			p, err := parseJSONPort(u.Hosts[i].Port)
+			if err != nil {
+				return err
+			}
+			buf = appendJSONHost(buf, u.Hosts[i].Host, p)
+		}
+	} else {
+		buf = appendJSONHost(buf, u.Host, port)
+	}
+	if opaque {
+		buf = append(buf, u.Path...)
+	} else {
+		if len(u.Path) > 0 && u.Path[0] != '/' {
+			buf = append(buf, '/')
+		}
+		buf = bufEscape(buf, byteconv.S2B(u.Path), modeFullPath)
+	}
+	if buf, err = appendJSONQuery(buf, u.Query); err != nil {
+		return err
+	}
+	if len(u.Hash) > 0 {
+		buf = append(buf, '#')
+		buf = append(buf, u.Hash...)
+	}
+	vec.tmp = buf
+	return vec.parse(buf, false)
+}
+
+// JSON representation of URL.
+type jsonURL struct {
+	Scheme   string          `json:"scheme"`
+	Username string          `json:"username"`
+	Password string          `json:"password"`
+	Host     string          `json:"host"`
+	Port     json.RawMessage `json:"port"`
+	Hosts    []jsonHost      `json:"hosts"`
+	Path     string          `json:"path"`
+	Query    json.RawMessage `json:"query"`
+	Hash     string          `json:"hash"`
+}
+
+// JSON representation of host of multiple hosts URL.
+type jsonHost struct {
+	Host string          `json:"host"`
+	Port json.RawMessage `json:"port"`
+}
+
+// Get port from number or string.
+func (u *jsonURL) port() (string, error) {
+	return parseJSONPort(u.Port)
+}
+
+// Append host and optional port to buf. IPv6 address encloses to brackets.
+func appendJSONHost(buf []byte, host, port string) []byte {
+	if strings.IndexByte(host, ':') >= 0 && host[0] != '[' {
+		buf = append(buf, '[')
+		buf = append(buf, host...)
+		buf = append(buf, ']')
+	} else {
+		buf = append(buf, host...)
+	}
+	if len(port) > 0 {
+		buf = append(buf, ':')
+		buf = append(buf, port...)
+	}
+	return buf
+}
+
+// Get port from JSON number or string.
+func parseJSONPort(raw json.RawMessage) (string, error) {
+	port := byteconv.B2S(raw)
+	if len(port) == 0 || port == "null" {
+		return "", nil
+	}
+	if port[0] == '"' {
+		if err := json.Unmarshal(raw, &port); err != nil {
+			return "", err
+		}
+	}
+	if _, ok := parsePort(byteconv.S2B(port)); !ok {
+		return "", ErrBadJSON
+	}
+	return port, nil
+}
+
+// Append query object as query string to buf keeping params order.
+func appendJSONQuery(buf []byte, query json.RawMessage) ([]byte, error) {
+	if len(query) == 0 || bytes.Equal(query, bNull) {
+		return buf, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(query))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return buf, ErrBadJSON
+	}
+	buf = append(buf, '?')
+	offset := len(buf)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return buf, err
+		}
+		key, _ := t.(string)
+		if t, err = dec.Token(); err != nil {
+			return buf, err
+		}
+		if t != json.Delim('[') {
+			if buf, err = appendJSONParam(buf, offset, key, t); err != nil {
+				return buf, err
+			}
+			continue
+		}
+		for dec.More() {
+			if t, err = dec.Token(); err != nil {
+				return buf, err
+			}
+			if buf, err = appendJSONParam(buf, offset, key, t); err != nil {
+				return buf, err
+			}
+		}
+		// Skip closing bracket.
+		if _, err = dec.Token(); err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+// Append key=value pair with scalar JSON value to buf.
+func appendJSONParam(buf []byte, offset int, key string, value json.Token) ([]byte, error) {
+	if len(buf) > offset {
+		buf = append(buf, '&')
+	}
+	// Key escapes as well except array suffix.
+	k := byteconv.S2B(key)
+	arr := len(k) > len(bQB) && bytes.HasSuffix(k, bQB)
+	if arr {
+		k = k[:len(k)-len(bQB)]
+	}
+	buf = bufEscape(buf, k, modeQuery)
+	if arr {
+		buf = append(buf, bQB...)
+	}
+	buf = append(buf, '=')
+	switch x := value.(type) {
+	case string:
+		buf = bufEscape(buf, byteconv.S2B(x), modeQuery)
+	case json.Number:
+		buf = append(buf, x...)
+	case bool:
+		if x {
+			buf = append(buf, bTrue...)
+		} else {
+			buf = append(buf, bFalse...)
+		}
+	case nil:
+	default:
+		return buf, ErrBadJSON
+	}
+	return buf, nil
+}
+
+// Prepare nodes to marshal: parse query and actualize addresses of nodes pointing to the buffer.
+func (vec *Vector) actualize() {
+	if vec.Len() == 0 {
+		// Nothing parsed yet, root is nil and marshals to null.
+		return
+	}
+	_ = vec.Query()
+	if vec.opts&OptionDSN != 0 {
+		_ = vec.Hosts()
+	}
+	for i := idxScheme; i <= idxHosts; i++ {
+		_ = vec.getByIdx(i)
+	}
+}
+
+// JSON writer keeps the first write error to check it once at the end.
+type jsonWriter struct {
+	w      io.Writer
+	err    error
+	indent bool
+}
+
+// Write node to w as JSON. Root node writes as URL object, other nodes as is.
+func marshalNode(w io.Writer, node *vector.Node, indent bool) error {
+	jw := jsonWriter{w: w, indent: indent}
+	if node == nil {
+		jw.write(bNull)
+		return jw.err
+	}
+	if node.Index() == 0 && node.Type() == vector.TypeObject {
+		jw.writeURL(node)
+	} else {
+		jw.writeNode(node, 0)
+	}
+	return jw.err
+}
+
+func (jw *jsonWriter) write(p []byte) {
+	if jw.err == nil && len(p) > 0 {
+		_, jw.err = jw.w.Write(p)
+	}
+}
+
+// Write URL object composed of root children.
+func (jw *jsonWriter) writeURL(root *vector.Node) {
+	// Empty parts have no keys, so take children by fixed indexes instead of keys.
+	var parts [idxHosts + 1]*vector.Node
+	root.Each(func(_ int, node *vector.Node) {
+		if i := node.Index(); i < len(parts) {
+			parts[i] = node
+		}
+	})
+
+	var c int
+	jw.write(bJSONObjO)
+	c = jw.writeField(c, "scheme", parts[idxScheme], false)
+	c = jw.writeField(c, "username", parts[idxUsername], false)
+	c = jw.writeField(c, "password", parts[idxPassword], false)
+	if hosts := parts[idxHosts]; hosts != nil && hosts.Limit() > 1 {
+		c = jw.writeKey(c, "hosts", 1)
+		jw.writeHosts(hosts)
+	} else {
+		c = jw.writeField(c, "host", parts[idxHostname], false)
+		c = jw.writePort(c, parts[idxPort], 1)
+	}
+	if path := parts[idxPath]; path != nil {
+		c = jw.writeField(c, "path", path, path.Value().CheckBit(flagEscape))
+	}
+	if query := parts[idxQuery]; query != nil && query.Limit() > 0 {
+		c = jw.writeKey(c, "query", 1)
+		jw.writeQuery(query)
+	}
+	if hash := parts[idxHash]; hash != nil && hash.Value().Len() > 1 {
+		c = jw.writeKey(c, "hash", 1)
+		jw.writeString(bytes.TrimPrefix(hash.Value().RawBytes(), bHash), false)
+	}
+	jw.close(c, 0, bJSONObjC)
+}
+
+// Write hosts array of multiple hosts URL, each host writes as object with host and port.
+func (jw *jsonWriter) writeHosts(hosts *vector.Node) {
+	var c int
+	jw.write(bJSONArrO)
+	hosts.Each(func(_ int, host *vector.Node) {
+		jw.writeSep(c, 2)
+		var c1 int
+		jw.write(bJSONObjO)
+		if hostname := host.Get("hostname"); hostname != nil && hostname.Value().Len() > 0 {
+			c1 = jw.writeKey(c1, "host", 3)
+			jw.writeString(hostname.Value().RawBytes(), false)
+		}
+		c1 = jw.writePort(c1, host.Get("port"), 3)
+		jw.close(c1, 2, bJSONObjC)
+		c++
+	})
+	jw.close(c, 1, bJSONArrC)
+}
+
+// Write port field if node isn't empty. Returns count of written fields.
+func (jw *jsonWriter) writePort(c int, port *vector.Node, depth int) int {
+	if port == nil || port.Value().Len() == 0 {
+		return c
+	}
+	c = jw.writeKey(c, "port", depth)
+	jw.writeNode(port, depth)
+	return c
+}
+
+// Write string field if node isn't empty. Returns count of written fields.
+func (jw *jsonWriter) writeField(c int, key string, node *vector.Node, unesc bool) int {
+	if node == nil || node.Value().Len() == 0 {
+		return c
+	}
+	c = jw.writeKey(c, key, 1)
+	jw.writeString(node.Value().RawBytes(), unesc)
+	return c
+}
+
+// Write separator, indentation and key of c-th field.
+func (jw *jsonWriter) writeKey(c int, key string, depth int) int {
+	jw.writeSep(c, depth)
+	jw.writeString(byteconv.S2B(key), false)
+	if jw.indent {
+		jw.write(bJSONColonS)
+	} else {
+		jw.write(bJSONColon)
+	}
+	return c + 1
+}
+
+// Write separator and indentation before c-th element.
+func (jw *jsonWriter) writeSep(c, depth int) {
+	if c > 0 {
+		jw.write(bJSONComma)
+	}
+	jw.writeNL(depth)
+}
+
+// Write closing bracket considering count of elements.
+func (jw *jsonWriter) close(c, depth int, bracket []byte) {
+	if c > 0 {
+		jw.writeNL(depth)
+	}
+	jw.write(bracket)
+}
+
+func (jw *jsonWriter) writeNL(depth int) {
+	if !jw.indent {
+		return
+	}
+	jw.write(bJSONNL)
+	for i := 0; i < depth; i++ {
+		jw.write(bJSONIndent)
+	}
+}
+
+// Write query params as object. Unlike values, param keys keep escaped in nodes, so they decode while writing.
+func (jw *jsonWriter) writeQuery(query *vector.Node) {
+	var c int
+	jw.write(bJSONObjO)
+	query.Each(func(_ int, param *vector.Node) {
+		jw.writeSep(c, 2)
+		jw.writeStr(param.KeyBytes(), true, true)
+		if jw.indent {
+			jw.write(bJSONColonS)
+		} else {
+			jw.write(bJSONColon)
+		}
+		jw.writeNode(param, 2)
+		c++
+	})
+	jw.close(c, 1, bJSONObjC)
+}
+
+// Write arbitrary node considering its type.
+func (jw *jsonWriter) writeNode(node *vector.Node, depth int) {
+	switch node.Type() {
+	case vector.TypeObject:
+		var c int
+		jw.write(bJSONObjO)
+		node.Each(func(_ int, child *vector.Node) {
+			jw.writeSep(c, depth+1)
+			jw.writeString(child.KeyBytes(), false)
+			if jw.indent {
+				jw.write(bJSONColonS)
+			} else {
+				jw.write(bJSONColon)
+			}
+			jw.writeNode(child, depth+1)
+			c++
+		})
+		jw.close(c, depth, bJSONObjC)
+	case vector.TypeArray:
+		var c int
+		jw.write(bJSONArrO)
+		node.Each(func(_ int, child *vector.Node) {
+			jw.writeSep(c, depth+1)
+			jw.writeNode(child, depth+1)
+			c++
+		})
+		jw.close(c, depth, bJSONArrC)
+	case vector.TypeString:
+		jw.writeString(node.Bytes(), false)
+	case vector.TypeNumber:
+		// Parser is lenient, so number node may contain any string (invalid port).
+		switch b := node.Bytes(); {
+		case len(b) == 0:
+			jw.write(bNull)
+		case isDigits(b):
+			jw.write(b)
+		default:
+			jw.writeString(b, false)
+		}
+	case vector.TypeBool:
+		if bytes.Equal(node.Bytes(), bTrue) {
+			jw.write(bTrue)
+		} else {
+			jw.write(bFalse)
+		}
+	default:
+		jw.write(bNull)
+	}
+}
+
+// Write p as JSON string. Percent-encoded bytes decodes as in path if unesc flag is set.
+func (jw *jsonWriter) writeString(p []byte, unesc bool) {
+	jw.writeStr(p, unesc, false)
+}
+
+// Write p as JSON string decoding percent-encoded bytes (unesc) and plus signs (plus) as in query.
+//
+// Outside of query reserved characters keep escaped, since decoded they change path structure (/a%2Fb isn't /a/b).
+func (jw *jsonWriter) writeStr(p []byte, unesc, plus bool) {
+	jw.write(bJSONQuote)
+	var lo int
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if plus && c == '+' {
+			jw.write(p[lo:i])
+			jw.write(bChars[' ' : ' '+1])
+			lo = i + 1
+			continue
+		}
+		if unesc && isEscape(p, i) && (plus || !isReservedEscape(p, i)) {
+			// Escape sequence keeps as is if decoded bytes aren't valid UTF-8 or decoded percent sign looks like escape.
+			n := unescapedRuneLen(p, i)
+			if n == 0 {
+				i += 2
+				continue
+			}
+			jw.write(p[lo:i])
+			for j := 0; j < n; j++ {
+				c = hex[p[i+1]]<<4 | hex[p[i+2]]
+				if !jw.writeEsc(c) {
+					jw.write(bChars[c : c+1])
+				}
+				i += 3
+			}
+			i--
+			lo = i + 1
+			continue
+		}
+		if c < 0x20 || c == '"' || c == '\\' {
+			jw.write(p[lo:i])
+			jw.writeEsc(c)
+			lo = i + 1
+		}
+	}
+	jw.write(p[lo:])
+	jw.write(bJSONQuote)
+}
+
+// Write escape sequence of c if it needs to be escaped in JSON string.
+func (jw *jsonWriter) writeEsc(c byte) bool {
+	switch {
+	case c == '"':
+		jw.write(bJSONEscQ)
+	case c == '\\':
+		jw.write(bJSONEscBS)
+	case c == '\n':
+		jw.write(bJSONEscN)
+	case c == '\r':
+		jw.write(bJSONEscR)
+	case c == '\t':
+		jw.write(bJSONEscT)
+	case c < 0x20:
+		jw.write(bJSONEscU)
+		jw.write(bHexUp[c>>4 : c>>4+1])
+		jw.write(bHexUp[c&15 : c&15+1])
+	default:
+		return false
+	}
+	return true
+}
+
+// Check if p contains valid escape sequence at position i.
+func isEscape(p []byte, i int) bool {
+	return p[i] == '%' && i+2 < len(p) && hex[p[i+1]] != 16 && hex[p[i+2]] != 16
+}
+
+// Check if escape sequence at position i encodes reserved character (RFC 3986 section 2.2).
+func isReservedEscape(p []byte, i int) bool {
+	return strings.IndexByte(":/?#[]@!$&'()*+,;=", hex[p[i+1]]<<4|hex[p[i+2]]) >= 0
+}
+
+// Get count of escape sequences starting at position i which decode to valid UTF-8 character. Returns 0 if they
+// don't or if decoded percent sign followed by two hex digits (%2541), so decoding is ambiguous.
+func unescapedRuneLen(p []byte, i int) int {
+	var (
+		r [utf8.UTFMax]byte
+		n int
+	)
+	for j := i; n < len(r) && j < len(p) && isEscape(p, j); j += 3 {
+		r[n] = hex[p[j+1]]<<4 | hex[p[j+2]]
+		n++
+		if utf8.FullRune(r[:n]) {
+			break
+		}
+	}
+	if r[0] == '%' {
+		if j := i + 3; j+1 < len(p) && hex[p[j]] != 16 && hex[p[j+1]] != 16 {
+			return 0
+		}
+		return 1
+	}
+	c, size := utf8.DecodeRune(r[:n])
+	if c == utf8.RuneError && size <= 1 {
+		return 0
+	}
+	return size
+}
+
+// Check if p contains only decimal digits.
+func isDigits(p []byte) bool {
+	for i := 0; i < len(p); i++ {
+		if p[i] < '0' || p[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package urlvector
+
+import (
+	"bytes"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+var (
+	bComma = []byte(",")
+	bTo    = []byte("to")
+)
+
+// Mailto returns mailto URL node (https://www.rfc-editor.org/rfc/rfc6068) with the following children:
+// * to - array of recipients, including addresses from "to" header fields
+// * headers - object of header fields (subject, cc, body, ...)
+//
+// Note that mailto URL will be parsed by first attempt to access it.
+func (vec *Vector) Mailto() *vector.Node {
+	return vec.opaque()
+}
+
+// MailtoRecipients returns array node of mailto recipients.
+func (vec *Vector) MailtoRecipients() *vector.Node {
+	return vec.Mailto().Get("to")
+}
+
+// MailtoHeaders returns object node of mailto header fields.
+func (vec *Vector) MailtoHeaders() *vector.Node {
+	return vec.Mailto().Get("headers")
+}
+
+// AddMailtoRecipientBytes escapes and appends recipient address to mailto URL.
+func (vec *Vector) AddMailtoRecipientBytes(addr []byte) *Vector {
+	path := vec.Path().Value().RawBytes()
+	offset := vec.BufLen()
+	vec.Bufferize(path)
+	if len(path) > 0 {
+		vec.Bufferize(bComma)
+	}
+	vecEscape(vec, addr, modeMailto)
+	vec.resetOpaque()
+	return vec.setTail(vec.Path(), offset)
+}
+
+// AddMailtoRecipientString escapes and appends recipient address to mailto URL.
+func (vec *Vector) AddMailtoRecipientString(addr string) *Vector {
+	return vec.AddMailtoRecipientBytes(byteconv.S2B(addr))
+}
+
+// AddMailtoHeaderBytes escapes and appends header field to mailto URL.
+func (vec *Vector) AddMailtoHeaderBytes(name, value []byte) *Vector {
+	query := bytealg.TrimLeft(vec.QueryBytes(), bQM)
+	offset := vec.BufLen()
+	vec.Bufferize(bQM)
+	if len(query) > 0 {
+		vec.Bufferize(query)
+		vec.Bufferize(bAmp)
+	}
+	vecEscape(vec, name, modeMailto)
+	vec.Bufferize(bEq)
+	vecEscape(vec, value, modeMailto)
+	vec.resetQuery()
+	return vec.setTail(vec.queryOrigin(), offset)
+}
+
+// AddMailtoHeaderString escapes and appends header field to mailto URL.
+func (vec *Vector) AddMailtoHeaderString(name, value string) *Vector {
+	return vec.AddMailtoHeaderBytes(byteconv.S2B(name), byteconv.S2B(value))
+}
+
+// Parse mailto URL's opaque path to recipients and query to header fields.
+func (vec *Vector) parseMailto(node *vector.Node) {
+	node.Key().Init(bKeys, offsetMailto, lenMailto)
+	node.SetOffset(vec.Index.Len(2))
+
+	to, it := vec.AcquireChildWithType(node, 2, vector.TypeArray)
+	to.Key().Init(bKeys, offsetTo, lenTo)
+	to.SetOffset(vec.Index.Len(3))
+	headers, ih := vec.AcquireChildWithType(node, 2, vector.TypeObject)
+	headers.Key().Init(bKeys, offsetHeaders, lenHeaders)
+
+	// Unescaped recipients and header fields ("to" fields unescapes twice) fit the following space.
+	n := vec.Path().Value().Len() + 2*vec.QueryLen()
+	vec.reserve(vec.Path().Value().RawBytes(), n)
+	vec.reserve(vec.QueryBytes(), n)
+
+	vec.parseMailtoAddrs(to, vec.Path().Value().RawBytes())
+
+	// Addresses from "to" header fields complement recipients list.
+	hfields := bytealg.TrimLeft(vec.QueryBytes(), bQM)
+	for offset := 0; offset < len(hfields); {
+		i := bytealg.IndexByteAtBytes(hfields, '&', offset)
+		if i < 0 {
+			i = len(hfields)
+		}
+		if j := bytealg.IndexByteAtBytes(hfields[:i], '=', offset); j > 0 && bytes.EqualFold(hfields[offset:j], bTo) {
+			vec.parseMailtoAddrs(to, hfields[j+1:i])
+		}
+		offset = i + 1
+	}
+
+	headers.SetOffset(vec.Index.Len(3))
+	for offset := 0; offset < len(hfields); {
+		i := bytealg.IndexByteAtBytes(hfields, '&', offset)
+		if i < 0 {
+			i = len(hfields)
+		}
+		if j := bytealg.IndexByteAtBytes(hfields[:i], '=', offset); j > offset {
+			hfield, k := vec.AcquireChildWithType(headers, 3, vector.TypeString)
+			vec.initPtr(hfield.Key(), hfields, offset, j-offset)
+			vec.initUnescaped(hfield, hfields, j+1, i-j-1, modeMailto)
+			vec.ReleaseNode(k, hfield)
+		}
+		offset = i + 1
+	}
+
+	vec.ReleaseNode(it, to)
+	vec.ReleaseNode(ih, headers)
+	vec.ReleaseNode(node.Index(), node)
+}
+
+// Parse comma separated list of addresses to children of to node.
+func (vec *Vector) parseMailtoAddrs(to *vector.Node, origin []byte) {
+	for offset := 0; offset < len(origin); {
+		i := bytealg.IndexByteAtBytes(origin, ',', offset)
+		if i < 0 {
+			i = len(origin)
+		}
+		if lo, hi := trimSpaceIdx(origin, offset, i); hi > lo {
+			addr, j := vec.AcquireChildWithType(to, 3, vector.TypeString)
+			vec.initUnescaped(addr, origin, lo, hi-lo, modeMailto)
+			vec.ReleaseNode(j, addr)
+		}
+		offset = i + 1
+	}
+}
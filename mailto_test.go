@@ -0,0 +1,88 @@
+package urlvector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMailto(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("mailto:joe@example.com,jane+news%40example.org?To=bob@example.com&subject=Hello%20there&body=1+1%3D2")
+		if s := vec.SchemeString(); s != "mailto" {
+			t.Error("scheme mismatch", "need", "mailto", "got", s)
+		}
+		if u := vec.UsernameString(); u != "" {
+			t.Error("unexpected username", u)
+		}
+		to := vec.MailtoRecipients()
+		expect := []string{"joe@example.com", "jane+news@example.org", "bob@example.com"}
+		if to.Limit() != len(expect) {
+			t.Fatal("recipients count mismatch", "need", len(expect), "got", to.Limit())
+		}
+		for i := range expect {
+			if r := to.At(i).String(); r != expect[i] {
+				t.Error("recipient mismatch", "need", expect[i], "got", r)
+			}
+		}
+		headers := vec.MailtoHeaders()
+		if s := headers.GetString("subject"); s != "Hello there" {
+			t.Error("subject mismatch", "need", "Hello there", "got", s)
+		}
+		if b := headers.GetString("body"); b != "1+1=2" {
+			t.Error("body mismatch", "need", "1+1=2", "got", b)
+		}
+		if q := vec.QueryString(); q != "?To=bob@example.com&subject=Hello%20there&body=1+1%3D2" {
+			t.Error("query mismatch", "got", q)
+		}
+	})
+
+	t.Run("build", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("mailto:")
+		vec.AddMailtoRecipientString("joe@example.com").
+			AddMailtoRecipientString("a,b@example.com").
+			AddMailtoHeaderString("subject", "Q&A: 100%").
+			AddMailtoHeaderString("body", "see you")
+		expect := "mailto:joe@example.com,a%2Cb@example.com?subject=Q%26A:%20100%25&body=see%20you"
+		if s := vec.String(); s != expect {
+			t.Error("mailto assembly failed", "\nneed", expect, "\n got", s)
+		}
+		if r := vec.MailtoRecipients().At(1).String(); r != "a,b@example.com" {
+			t.Error("recipient mismatch", "need", "a,b@example.com", "got", r)
+		}
+		if s := vec.MailtoHeaders().GetString("subject"); s != "Q&A: 100%" {
+			t.Error("subject mismatch", "need", "Q&A: 100%", "got", s)
+		}
+	})
+
+	t.Run("buffer growth", func(t *testing.T) {
+		const src = "mailto:a%2Cb@example.com?to=c%40example.com&subject=Hi%20there"
+		for _, prep := range []func(){
+			func() { _ = vec.ParseString(src) },
+			func() { _ = vec.ParseCopyString(src) },
+			func() {
+				_ = vec.ParseString("mailto:")
+				vec.AddMailtoRecipientString("a,b@example.com").
+					AddMailtoHeaderString("to", "c@example.com").
+					AddMailtoHeaderString("subject", "Hi there")
+			},
+		} {
+			vec.Reset()
+			prep()
+			_ = vec.Mailto()
+			// Reallocate the buffer after parsing.
+			vec.SetHashString(strings.Repeat("h", 4096))
+			if r := vec.MailtoRecipients().At(0).String(); r != "a,b@example.com" {
+				t.Error("recipient mismatch", "need", "a,b@example.com", "got", r)
+			}
+			if r := vec.MailtoRecipients().At(1).String(); r != "c@example.com" {
+				t.Error("recipient mismatch", "need", "c@example.com", "got", r)
+			}
+			if s := vec.MailtoHeaders().GetString("subject"); s != "Hi there" {
+				t.Error("subject mismatch", "need", "Hi there", "got", s)
+			}
+			testAddr(t, vec, vec.Mailto())
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package urlvector
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/koykov/byteconv"
+)
+
+var (
+	// Common public suffixes of two labels uses if PublicSuffix isn't set.
+	publicSuffixes2 = []string{
+		"co.uk", "org.uk", "ac.uk", "gov.uk", "me.uk", "ltd.uk", "plc.uk", "net.uk",
+		"com.au", "net.au", "org.au", "edu.au", "gov.au",
+		"co.nz", "org.nz", "net.nz",
+		"co.jp", "ne.jp", "or.jp", "ac.jp", "go.jp",
+		"co.kr", "or.kr",
+		"com.br", "net.br", "org.br",
+		"com.cn", "net.cn", "org.cn", "gov.cn",
+		"com.tr", "com.mx", "com.ar", "com.sg", "com.hk", "com.tw", "co.in", "co.za", "co.il",
+		"github.io", "gitlab.io", "herokuapp.com", "appspot.com", "blogspot.com", "pages.dev", "workers.dev",
+		"netlify.app", "vercel.app", "web.app", "firebaseapp.com", "cloudfront.net", "azurewebsites.net",
+	}
+)
+
+// PublicSuffix returns public suffix of lower case domain. Function has the same signature as
+// golang.org/x/net/publicsuffix.PublicSuffix and may be replaced by it to get precise registrable domains:
+//
+//	urlvector.PublicSuffix = publicsuffix.PublicSuffix
+//
+// Default implementation knows only top level domains and a short list of common suffixes (co.uk, github.io, ...).
+var PublicSuffix = func(domain string) (string, bool) {
+	for _, suffix := range publicSuffixes2 {
+		if strings.HasSuffix(domain, suffix) && (len(domain) == len(suffix) || domain[len(domain)-len(suffix)-1] == '.') {
+			return suffix, true
+		}
+	}
+	return domain[strings.LastIndexByte(domain, '.')+1:], true
+}
+
+// AppendOrigin appends ASCII serialization of URL origin (scheme://host[:port]) to dst and returns the extended
+// buffer. Scheme and host writes in lower case, default port of scheme omits. Opaque origin (file:, data:, non-special
+// schemes, invalid port) serializes as "null".
+func (vec *Vector) AppendOrigin(dst []byte) []byte {
+	port, ok := vec.originPort()
+	if !ok {
+		return append(dst, bNull...)
+	}
+	dst = appendLower(dst, vec.SchemeBytes())
+	dst = append(dst, bSchemaSep...)
+	dst = appendLower(dst, vec.HostnameBytes())
+	if p, ok := vec.PortValue(); ok && int(p) != port {
+		dst = append(dst, bColon...)
+		dst = strconv.AppendUint(dst, uint64(p), 10)
+	}
+	return dst
+}
+
+// Origin returns ASCII serialization of URL origin, see AppendOrigin().
+func (vec *Vector) Origin() string {
+	vec.out = vec.AppendOrigin(vec.out[:0])
+	return string(vec.out)
+}
+
+// OpaqueOrigin checks if URL has opaque origin.
+func (vec *Vector) OpaqueOrigin() bool {
+	_, ok := vec.originPort()
+	return !ok
+}
+
+// SameOrigin checks if both URLs have the same scheme, host and port (considering default ports).
+//
+// Opaque origin is the same only to itself.
+func (vec *Vector) SameOrigin(other *Vector) bool {
+	if vec == other {
+		return true
+	}
+	if vec.OpaqueOrigin() || other.OpaqueOrigin() {
+		return false
+	}
+	return vec.EffectivePort() == other.EffectivePort() &&
+		bytes.EqualFold(vec.SchemeBytes(), other.SchemeBytes()) &&
+		bytes.EqualFold(trimDot(vec.HostnameBytes()), trimDot(other.HostnameBytes()))
+}
+
+// SameSite checks if both URLs belongs to the same site, i.e. have the same registrable domain (or host if domain
+// isn't registrable). Schemeful check requires the same scheme as well (http and https are different sites).
+//
+// Opaque origin is the same site only to itself.
+func (vec *Vector) SameSite(other *Vector, schemeful bool) bool {
+	if vec == other {
+		return true
+	}
+	if vec.OpaqueOrigin() || other.OpaqueOrigin() {
+		return false
+	}
+	if schemeful && !bytes.EqualFold(vec.SchemeBytes(), other.SchemeBytes()) {
+		return false
+	}
+	site, site1 := vec.RegistrableDomainBytes(), other.RegistrableDomainBytes()
+	if len(site) == 0 || len(site1) == 0 {
+		site, site1 = trimDot(vec.HostnameBytes()), trimDot(other.HostnameBytes())
+	}
+	return bytes.EqualFold(site, site1)
+}
+
+// RegistrableDomainBytes returns registrable domain of host (public suffix plus one label, e.g. example.co.uk for
+// www.example.co.uk). Returns nil if host is an IP address or a public suffix itself.
+//
+// Result is a part of hostname, so case keeps as is.
+func (vec *Vector) RegistrableDomainBytes() []byte {
+	host := trimDot(vec.HostnameBytes())
+	if len(host) == 0 || host[0] == '[' || endsInNumber(host) {
+		return nil
+	}
+	domain := byteconv.B2S(host)
+	if hasUpper(host) {
+		domain = strings.ToLower(domain)
+	}
+	suffix, _ := PublicSuffix(domain)
+	if len(suffix) >= len(host) {
+		return nil
+	}
+	i := bytes.LastIndexByte(host[:len(host)-len(suffix)-1], '.')
+	return host[i+1:]
+}
+
+// RegistrableDomainString returns registrable domain as string.
+func (vec *Vector) RegistrableDomainString() string {
+	return byteconv.B2S(vec.RegistrableDomainBytes())
+}
+
+// Get default port of tuple origin. False means opaque origin.
+func (vec *Vector) originPort() (int, bool) {
+	if vec.Len() == 0 || vec.CheckBit(flagOpaque) || len(vec.HostnameBytes()) == 0 {
+		return 0, false
+	}
+	// Special schemes except file have tuple origin (https://url.spec.whatwg.org/#concept-url-origin).
+	spec, ok := vec.SchemeSpec()
+	if !ok || !spec.Special || bytes.EqualFold(vec.SchemeBytes(), bFile) {
+		return 0, false
+	}
+	// URL with invalid port fails to parse by WHATWG URL parser, so it has no tuple origin.
+	if p := vec.getByIdx(idxPort).Bytes(); len(p) > 0 {
+		if _, ok = parsePort(p); !ok {
+			return 0, false
+		}
+	}
+	return spec.DefaultPort, true
+}
+
+func appendLower(dst, p []byte) []byte {
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+func hasUpper(p []byte) bool {
+	for i := 0; i < len(p); i++ {
+		if p[i] >= 'A' && p[i] <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if last label of host is a number, so host is an IPv4 address
+// (https://url.spec.whatwg.org/#ends-in-a-number-checker).
+func endsInNumber(host []byte) bool {
+	label := host[bytes.LastIndexByte(host, '.')+1:]
+	if len(label) > 1 && label[0] == '0' && (label[1] == 'x' || label[1] == 'X') {
+		label = label[2:]
+		for i := 0; i < len(label); i++ {
+			if hex[label[i]] == 16 {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i < len(label); i++ {
+		if label[i] < '0' || label[i] > '9' {
+			return false
+		}
+	}
+	return len(label) > 0
+}
+
+// Trim trailing dot of fully qualified domain name.
+func trimDot(host []byte) []byte {
+	if len(host) > 1 && host[len(host)-1] == '.' {
+		return host[:len(host)-1]
+	}
+	return host
+}
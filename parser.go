@@ -24,6 +24,27 @@ const (
 	offsetHash        = 68
 	offsetTrue        = 72
 	offsetQuery       = 76
+	offsetFragment    = 81
+	offsetText        = 89
+	offsetPrefix      = 93
+	offsetStart       = 99
+	offsetEnd         = 104
+	offsetSuffix      = 107
+	offsetUnit        = 113
+	offsetXYWH        = 117
+	offsetTrack       = 121
+	offsetID          = 126
+	offsetNPT         = 128
+	offsetPixel       = 131
+	offsetData        = 136
+	offsetMediaType   = 140
+	offsetParams      = 149
+	offsetBase64      = 155
+	offsetTextPlain   = 161
+	offsetMailto      = 171
+	offsetTo          = 175
+	offsetHeaders     = 177
+	offsetHosts       = 184
 
 	// Length of keys substrings in bKeys.
 	lenScheme      = 6
@@ -39,6 +60,27 @@ const (
 	lenHash        = 4
 	lenTrue        = 4
 	lenQuery       = 5
+	lenFragment    = 8
+	lenText        = 4
+	lenPrefix      = 6
+	lenStart       = 5
+	lenEnd         = 3
+	lenSuffix      = 6
+	lenUnit        = 4
+	lenXYWH        = 4
+	lenTrack       = 5
+	lenID          = 2
+	lenNPT         = 3
+	lenPixel       = 5
+	lenData        = 4
+	lenMediaType   = 9
+	lenParams      = 6
+	lenBase64      = 6
+	lenTextPlain   = 10
+	lenMailto      = 6
+	lenTo          = 2
+	lenHeaders     = 7
+	lenHosts       = 5
 
 	// Max scheme length by https://en.wikipedia.org/wiki/List_of_URI_schemes#Official_IANA-registered_schemes.
 	maxSchemaLen = 29
@@ -58,9 +100,21 @@ var (
 	bHash      = []byte("#")
 	bQB        = []byte("[]")
 	bEq        = []byte("=")
+	bData      = []byte("data")
+	bMailto    = []byte("mailto")
+	bFile      = []byte("file")
+	bLocalhost = []byte("localhost")
+	bHostEnd   = []byte("/\\?#")
+
+	// Schemes with opaque path (without authority part).
+	opaqueSchemes = [][]byte{bData, bMailto}
 
 	// Keys source array and raw address of it.
-	bKeys = []byte("schemeslashesauthusernamepasswordhosthostnameportpathnamequeryoriginhashtruequery")
+	bKeys = []byte("schemeslashesauthusernamepasswordhosthostnameportpathnamequeryoriginhashtruequery" +
+		"fragmenttextprefixstartendsuffixunitxywhtrackidnptpixel" +
+		"datamediatypeparamsbase64text/plain" +
+		"mailtoheaders" +
+		"hosts")
 
 	errBadInit = errors.New("bad vector initialization, use urlvector.NewVector() or urlvector.Acquire()")
 )
@@ -71,10 +125,11 @@ func (vec *Vector) parse(s []byte, copy bool) (err error) {
 		err = errBadInit
 		return
 	}
+	vec.touch()
 
 	s = bytealg.Trim(s, bSpace)
-	// Remove blob: prefix if present.
-	if len(s) >= 5 && bytes.Equal(s[:5], bBlob) {
+	// Remove blob: prefixes if present. Nested prefixes removes as well to parse serialized URL to the same.
+	for len(s) >= 5 && bytes.Equal(s[:5], bBlob) {
 		s = s[5:]
 	}
 	if err = vec.SetSrc(s, copy); err != nil {
@@ -92,11 +147,11 @@ func (vec *Vector) parse(s []byte, copy bool) (err error) {
 		vec.SetErrOffset(offset)
 		return
 	}
-	if offset, err = vec.parseAuth(1, offset, root); err != nil {
-		vec.SetErrOffset(offset)
-		return
-	}
-	if offset, err = vec.parseHost(1, offset, root); err != nil {
+	if vec.CheckBit(flagOpaque) {
+		vec.skipAuthority(1, root)
+	} else if vec.CheckBit(flagSCP) {
+		offset = vec.parseSCP(1, offset, root)
+	} else if offset, err = vec.parseAuthority(1, offset, root); err != nil {
 		vec.SetErrOffset(offset)
 		return
 	}
@@ -129,18 +184,34 @@ func (vec *Vector) parseScheme(depth, offset int, node *vector.Node) (int, error
 	scheme, isc := vec.AcquireChildWithType(node, depth, vector.TypeString)
 	slashes, isl := vec.AcquireChildWithType(node, depth, vector.TypeBool)
 
-	limit := maxSchemaLen
-	if sl := vec.SrcLen(); sl < limit {
-		limit = sl
-	}
-	if limit < 2 {
-		return offset, vector.ErrShortSrc
-	}
-	if pos := bytes.Index(vec.Src(), bSchemaSep); pos > 0 {
+	vec.SetBit(flagOpaque, false)
+	vec.SetBit(flagFile, false)
+	vec.SetBit(flagSCP, false)
+	if pos := opaqueSchemeLen(vec.Src()); pos > 0 {
+		scheme.Key().Init(bKeys, offsetScheme, lenScheme)
+		scheme.Value().Init(vec.Src(), offset, pos)
+		offset += pos + 1
+		vec.SetBit(flagOpaque, true)
+	} else if pos = fileSchemeLen(vec.Src()); pos > 0 {
+		// File URL may omit slashes (file:/etc/hosts, file:C:/x), so it can't be detected by "://" separator.
+		scheme.Key().Init(bKeys, offsetScheme, lenScheme)
+		scheme.Value().Init(vec.Src(), offset, len(bFile))
+		offset += pos
+		vec.SetBit(flagFile, true)
+	} else if vec.opts&OptionSCP != 0 && scpColIdx(vec.Src()) > 0 {
+		// Scp-like remote has no scheme, authority will be parsed by parseSCP().
+		vec.SetBit(flagSCP, true)
+	} else if pos = schemeLen(vec.Src()); pos > 0 && bytes.HasPrefix(vec.Src()[pos:], bSchemaSep) {
 		scheme.Key().Init(bKeys, offsetScheme, lenScheme)
 		scheme.Value().Init(vec.Src(), offset, pos)
 		offset += pos + 3
-	} else if bytes.Equal(vec.Src()[:2], bSlashes) {
+	} else if pos > 0 && isOpaqueTail(vec.Src(), pos) {
+		// Generic opaque URL (urn:isbn:0451450523, tel:+1-201-555-0123, about:blank).
+		scheme.Key().Init(bKeys, offsetScheme, lenScheme)
+		scheme.Value().Init(vec.Src(), offset, pos)
+		offset += pos + 1
+		vec.SetBit(flagOpaque, true)
+	} else if bytes.HasPrefix(vec.Src(), bSlashes) {
 		slashes.Key().Init(bKeys, offsetSlashes, lenSlashes)
 		slashes.Value().Init(bKeys, offsetTrue, lenTrue)
 		offset += 2
@@ -152,6 +223,38 @@ func (vec *Vector) parseScheme(depth, offset int, node *vector.Node) (int, error
 	return offset, err
 }
 
+// Register empty authority nodes for URLs with opaque path.
+// Parse user info and host, check ports in strict mode.
+func (vec *Vector) parseAuthority(depth, offset int, node *vector.Node) (int, error) {
+	offset, err := vec.parseAuth(depth, offset, node)
+	if err != nil {
+		return offset, err
+	}
+	if offset, err = vec.parseHost(depth, offset, node); err != nil {
+		return offset, err
+	}
+	if vec.opts&OptionStrict != 0 {
+		return vec.checkPorts(offset)
+	}
+	return offset, nil
+}
+
+func (vec *Vector) skipAuthority(depth int, node *vector.Node) {
+	auth, ia := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	username, iu := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	password, ip := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	host, ih := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	hostname, in := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	port, ipt := vec.AcquireChildWithType(node, depth, vector.TypeNumber)
+
+	vec.relNode(ia, auth)
+	vec.relNode(iu, username)
+	vec.relNode(ip, password)
+	vec.relNode(ih, host)
+	vec.relNode(in, hostname)
+	vec.relNode(ipt, port)
+}
+
 // Parse auth (username + password) and separate username and password parts.
 func (vec *Vector) parseAuth(depth, offset int, node *vector.Node) (int, error) {
 	var err error
@@ -164,10 +267,18 @@ func (vec *Vector) parseAuth(depth, offset int, node *vector.Node) (int, error)
 	n := len(src)
 	_ = src[n-1]
 
-	posCol := bytealg.IndexByteAtBytes(src, ':', offset)
-	posAt := bytealg.IndexByteAtBytes(src, '@', max_(posCol, offset))
-	if posSl := bytealg.IndexByteAtBytes(src, '/', offset); posSl >= 0 && posSl < posAt {
-		posAt = -1
+	// User info ends by the last "@" of authority, since unescaped "@" may occur in username or password.
+	posEnd := bytealg.IndexAnyAtBytes(src, bAuthorityEnd, offset)
+	if posEnd < 0 {
+		posEnd = n
+	}
+	posAt := bytes.LastIndexByte(src[offset:posEnd], '@')
+	if posAt >= 0 {
+		posAt += offset
+	}
+	posCol := -1
+	if posAt > 0 {
+		posCol = bytealg.IndexByteAtBytes(src[:posAt], ':', offset)
 	}
 
 	if posAt > 0 {
@@ -207,24 +318,32 @@ func (vec *Vector) parseHost(depth, offset int, node *vector.Node) (int, error)
 	n := len(src)
 	_ = src[n-1]
 
-	posSl := bytealg.IndexByteAtBytes(src, '/', offset)
-	if posSl < 0 {
-		if posBSl := bytealg.IndexByteAtBytes(src, '\\', offset); posBSl >= 0 {
-			posSl = posBSl
-		} else if posQM := bytealg.IndexByteAtBytes(src, '?', offset); posQM >= 0 {
-			posSl = posQM
-		} else {
+	var posSl int
+	if vec.opts&OptionDSN != 0 {
+		// Hosts list ends by path, query or hash.
+		if posSl = bytealg.IndexAnyAtBytes(src, bAuthorityEnd, offset); posSl < 0 {
 			posSl = n
 		}
+	} else if posSl = bytealg.IndexAnyAtBytes(src, bHostEnd, offset); posSl < 0 {
+		// Host ends by any kind of slash, query or hash.
+		posSl = n
 	}
-	posCol := -1
-	i := offset
-loop:
-	i = bytealg.IndexByteAtBytes(src, ':', i+1)
-	if i >= 0 && i < posSl {
-		posCol = i
-		goto loop
+	if vec.CheckBit(flagFile) {
+		// Drive letter belongs to the path and localhost means empty host.
+		if h := src[offset:posSl]; isDriveLetter(h) {
+			posSl = offset
+		} else if bytes.EqualFold(h, bLocalhost) {
+			offset = posSl
+		}
+	}
+	// Hostname and port of the first host in the list.
+	posEnd := posSl
+	if vec.opts&OptionDSN != 0 {
+		if posCm := bytealg.IndexByteAtBytes(src, ',', offset); posCm >= 0 && posCm < posSl {
+			posEnd = posCm
+		}
 	}
+	posCol := portColIdx(src, offset, posEnd)
 
 	host.Key().Init(bKeys, offsetHost, lenHost)
 	host.Value().Init(src, offset, posSl-offset)
@@ -235,10 +354,10 @@ loop:
 		offset = posCol + 1
 
 		port.Key().Init(bKeys, offsetPort, lenPort)
-		port.Value().Init(src, offset, posSl-offset)
+		port.Value().Init(src, offset, posEnd-offset)
 	} else {
 		hostname.Key().Init(bKeys, offsetHostname, lenHostname)
-		hostname.Value().Init(src, offset, posSl-offset)
+		hostname.Value().Init(src, offset, posEnd-offset)
 	}
 
 	vec.relNode(ih, host)
@@ -276,7 +395,11 @@ func (vec *Vector) parsePath(depth, offset int, node *vector.Node) (int, error)
 		path.Key().Init(bKeys, offsetPath, lenPath)
 		val := src[offset:posQM]
 		path.Value().Init(src, offset, posQM-offset)
-		path.Value().SetBit(flagEscape, bytealg.IndexByteAtBytes(val, '%', 0) >= 0)
+		// Opaque path keeps escaped as is, since its unescape depends on scheme.
+		path.Value().SetBit(flagEscape, !vec.CheckBit(flagOpaque) && bytealg.IndexByteAtBytes(val, '%', 0) >= 0)
+		if vec.CheckBit(flagFile) {
+			vec.normFilePath(path)
+		}
 		offset = posQM
 	}
 
@@ -295,6 +418,10 @@ func (vec *Vector) parseQuery(depth, offset int, node *vector.Node) (int, error)
 	queryOrig, iqo := vec.AcquireChildWithType(node, depth, vector.TypeString)
 	hash, ih := vec.AcquireChildWithType(node, depth, vector.TypeString)
 	query, iq := vec.AcquireChildWithType(node, depth, vector.TypeObject)
+	fragment, ifr := vec.AcquireChildWithType(node, depth, vector.TypeObject)
+	opaque, iop := vec.AcquireChildWithType(node, depth, vector.TypeObject)
+	hosts, ihs := vec.AcquireChildWithType(node, depth, vector.TypeArray)
+	hosts.Key().Init(bKeys, offsetHosts, lenHosts)
 
 	src := vec.Src()
 	n := len(src)
@@ -309,6 +436,7 @@ func (vec *Vector) parseQuery(depth, offset int, node *vector.Node) (int, error)
 			hash.Value().Init(src, posHash, n-posHash)
 		}
 		query.Key().Init(bKeys, offsetQuery, lenQuery)
+		fragment.Key().Init(bKeys, offsetFragment, lenFragment)
 		queryOrig.Key().Init(bKeys, offsetQueryOrigin, lenQueryOrigin)
 		queryOrig.Value().Init(src, offset, posHash-offset)
 		offset = n
@@ -317,13 +445,21 @@ func (vec *Vector) parseQuery(depth, offset int, node *vector.Node) (int, error)
 	vec.relNode(iqo, queryOrig)
 	vec.relNode(ih, hash)
 	vec.relNode(iq, query)
+	vec.relNode(ifr, fragment)
+	vec.relNode(iop, opaque)
+	vec.relNode(ihs, hosts)
 
 	return offset, err
 }
 
 // Parse query string to separate arguments.
 func (vec *Vector) parseQueryParams(query *vector.Node) {
-	origin := bytealg.TrimLeft(vec.QueryBytes(), bQM)
+	vec.reserve(vec.QueryBytes(), vec.QueryLen())
+	vec.parseParams(query, bytealg.TrimLeft(vec.QueryBytes(), bQM), 2)
+}
+
+// Parse origin as list of key=value pairs to children of node. Depth indicates depth of the children.
+func (vec *Vector) parseParams(query *vector.Node, origin []byte, depth int) {
 	if len(origin) == 0 {
 		return
 	}
@@ -353,29 +489,29 @@ func (vec *Vector) parseQueryParams(query *vector.Node) {
 			}
 		}
 		if len(k) == 0 {
-			offset = i + 1
+			if offset = i + 1; offset >= len(origin) {
+				break
+			}
 			continue
 		}
 
 		if kl := len(k); kl > 2 && bytes.Equal(k[kl-2:], bQB) {
 			if root = query.Get(byteconv.B2S(k)); root.Type() != vector.TypeArray {
-				root, _ = vec.AcquireChildWithType(query, 2, vector.TypeArray)
-				root.SetOffset(vec.Index.Len(3))
-				root.Key().Init(origin, offset, len(k))
+				root, _ = vec.AcquireChildWithType(query, depth, vector.TypeArray)
+				root.SetOffset(vec.Index.Len(depth + 1))
+				vec.initPtr(root.Key(), origin, offset, len(k))
 			}
-			node, idx = vec.AcquireChildWithType(root, 3, vector.TypeString)
+			node, idx = vec.AcquireChildWithType(root, depth+1, vector.TypeString)
 			if len(v) > 0 {
-				v = unescape(v)
-				node.Value().Init(origin, offset+len(k)+1, len(v))
+				vec.initParam(node, origin, offset+len(k)+1, len(v))
 			}
 			vec.relNode(idx, node)
 			vec.ReleaseNode(root.Index(), root)
 		} else {
-			node, idx = vec.AcquireChildWithType(query, 2, vector.TypeString)
-			node.Key().Init(origin, offset, len(k))
+			node, idx = vec.AcquireChildWithType(query, depth, vector.TypeString)
+			vec.initPtr(node.Key(), origin, offset, len(k))
 			if len(v) > 0 {
-				v = unescape(v)
-				node.Value().Init(origin, offset+len(k)+1, len(v))
+				vec.initParam(node, origin, offset+len(k)+1, len(v))
 			}
 			vec.relNode(idx, node)
 		}
@@ -388,22 +524,133 @@ func (vec *Vector) parseQueryParams(query *vector.Node) {
 	vec.relNode(query.Index(), query)
 }
 
+// Init node's value with origin[offset:offset+length].
+//
+// Escaped value will be unescaped to the buffer according mode, since in-place unescape may damage origin.
+func (vec *Vector) initUnescaped(node *vector.Node, origin []byte, offset, length int, mode mode) {
+	val := origin[offset : offset+length]
+	if !needUnescape(val, mode) {
+		vec.initPtr(node.Value(), origin, offset, length)
+		return
+	}
+	o := vec.BufLen()
+	vec.BufReplaceWith(bufUnescape(vec.Buf(), val, mode))
+	vec.SetBit(flagBufMod, true)
+	node.Value().Init(vec.Buf(), o, vec.BufLen()-o)
+	node.Value().SetBit(flagBufSrc, true)
+}
+
+// Init node's value with query param origin[offset:offset+length] unescaped.
+//
+// Copied source belongs to the vector and unescapes in-place, otherwise source may be immutable (string) and value
+// unescapes to the buffer.
+func (vec *Vector) initParam(node *vector.Node, origin []byte, offset, length int) {
+	if !vec.CheckBit(flagCopy) {
+		vec.initUnescaped(node, origin, offset, length, modeQuery)
+		return
+	}
+	v := unescape(origin[offset:offset+length], modeQuery)
+	vec.initPtr(node.Value(), origin, offset, len(v))
+}
+
+// Init p with origin[offset:offset+length].
+//
+// Origin placed in the buffer addresses relative to the buffer, so p will be re-addressed after buffer growth (see
+// rebase()). Origin must stay in the buffer while parsing, see reserve().
+func (vec *Vector) initPtr(p *vector.Byteptr, origin []byte, offset, length int) {
+	if base := ptrOffset(vec.Buf(), origin); base >= 0 {
+		p.Init(vec.Buf(), base+offset, length)
+		p.SetBit(flagBufSrc, true)
+		return
+	}
+	p.Init(origin, offset, length)
+}
+
+// Grow the buffer to fit n bytes more without reallocation if origin placed in the buffer.
+//
+// Lazy parsers unescapes values to the buffer, so they reserve space before parsing to keep buffered origin in place.
+// Origin must be taken again after the call.
+func (vec *Vector) reserve(origin []byte, n int) {
+	buf := vec.Buf()
+	if ptrOffset(buf, origin) < 0 || cap(buf)-len(buf) >= n {
+		return
+	}
+	vec.BufReplaceWith(append(buf, make([]byte, n)...)[:len(buf)])
+	vec.SetBit(flagBufMod, true)
+}
+
 // Call vector.ReleaseNode() and set required flags before.
 func (vec *Vector) relNode(idx int, node *vector.Node) {
 	vec.ensureFlags(node)
 	vec.ReleaseNode(idx, node)
 }
 
-// Consider source origin and set flags.
+// Consider source origin and set flags. Copied source belongs to the buffer, so values pointing to it must be
+// re-addressed after buffer growth, static values (e.g. slashes) keep as is.
 func (vec *Vector) ensureFlags(node *vector.Node) {
-	if vec.CheckBit(flagCopy) {
+	if vec.CheckBit(flagCopy) && ptrOffset(vec.Src(), node.Value().RawBytes()) >= 0 {
 		node.Value().SetBit(flagBufSrc, true)
 	}
 }
 
-func max_(a, b int) int {
-	if a > b {
-		return a
+// Get length of opaque scheme if source starts with it.
+func opaqueSchemeLen(src []byte) int {
+	for i := 0; i < len(opaqueSchemes); i++ {
+		l := len(opaqueSchemes[i])
+		if len(src) > l && src[l] == ':' && bytes.EqualFold(src[:l], opaqueSchemes[i]) {
+			return l
+		}
+	}
+	return 0
+}
+
+// Get length of scheme followed by colon or -1 if source doesn't start with valid scheme.
+//
+// Scheme must start with a letter followed by letters, digits, "+", "-" or ".", see RFC 3986 section 3.1.
+func schemeLen(src []byte) int {
+	for i := 0; i < len(src) && i <= maxSchemaLen; i++ {
+		c := src[i]
+		switch {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		case i > 0 && c == ':':
+			return i
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// Check if scheme ended at pos is followed by opaque path.
+//
+// Scheme-less host with port (localhost:8080/path) and user info (user:pass@host) aren't opaque URLs.
+func isOpaqueTail(src []byte, pos int) bool {
+	tail := src[pos+1:]
+	end := bytealg.IndexAnyAtBytes(tail, bAuthorityEnd, 0)
+	if end < 0 {
+		end = len(tail)
+	}
+	if bytealg.IndexByteAtBytes(tail[:end], '@', 0) >= 0 {
+		return false
+	}
+	for i := 0; i < end; i++ {
+		if tail[i] < '0' || tail[i] > '9' {
+			return true
+		}
+	}
+	// Empty port followed by query or hash (magnet:?xt=...).
+	return end == 0 && len(tail) > 0 && tail[0] != '/'
+}
+
+// Get length of file scheme prefix including separator (file:, file:// or file:\\).
+func fileSchemeLen(src []byte) int {
+	l := len(bFile)
+	if len(src) <= l || src[l] != ':' || !bytes.EqualFold(src[:l], bFile) {
+		return 0
+	}
+	if len(src) > l+2 && (src[l+1] == '/' || src[l+1] == '\\') && (src[l+2] == '/' || src[l+2] == '\\') {
+		return l + 3
 	}
-	return b
+	return l + 1
 }
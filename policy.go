@@ -0,0 +1,235 @@
+package urlvector
+
+import (
+	"bytes"
+	"net/netip"
+	"strconv"
+	"syscall"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+)
+
+// Policy describes restrictions of outbound URLs to prevent server-side request forgery (SSRF), see Check().
+//
+// Zero policy allows any scheme, port and host, but denies non-public IP addresses, localhost domains and credentials.
+type Policy struct {
+	// Schemes contains allowed schemes, any scheme allows if empty.
+	Schemes []string
+	// Ports contains allowed ports. Port considers with scheme default, see EffectivePort(). Any port allows if empty.
+	Ports []uint16
+	// AllowHosts contains allowed hosts, any host allows if empty. Pattern "*.example.com" matches any subdomain of
+	// example.com, but not example.com itself. IP address matches in canonical form (127.0.0.1, ::1).
+	AllowHosts []string
+	// DenyHosts contains denied hosts in the same format as AllowHosts. Takes precedence over AllowHosts.
+	DenyHosts []string
+	// AllowPrivate allows non-public IP addresses (loopback, private, link-local, ...) and localhost domains.
+	AllowPrivate bool
+	// DenyPrefixes contains additionally denied networks.
+	DenyPrefixes []netip.Prefix
+	// AllowCredentials allows username and password in URL.
+	AllowCredentials bool
+	// MaxLength limits length of URL, zero means no limit.
+	MaxLength int
+}
+
+// Printable forbidden domain code points (https://url.spec.whatwg.org/#forbidden-domain-code-point), control ones
+// checks separately.
+var bHostForbidden = []byte(" #/:<>?@[\\]^|%")
+
+// Rule represents policy rule violated by URL.
+type Rule uint
+
+const (
+	RuleScheme Rule = iota + 1
+	RulePort
+	RuleHost
+	RuleAddress
+	RuleCredentials
+	RuleLength
+)
+
+// Violation describes why URL isn't allowed by policy.
+type Violation struct {
+	// Rule is the violated rule.
+	Rule Rule
+	// Value is the offending part of URL (scheme, port, host, IP address or length).
+	Value string
+}
+
+func (v *Violation) Error() string {
+	switch v.Rule {
+	case RuleScheme:
+		return "scheme " + strconv.Quote(v.Value) + " isn't allowed"
+	case RulePort:
+		return "port " + strconv.Quote(v.Value) + " isn't allowed"
+	case RuleHost:
+		if len(v.Value) == 0 {
+			return "missing host"
+		}
+		return "host " + strconv.Quote(v.Value) + " isn't allowed"
+	case RuleAddress:
+		return "address " + strconv.Quote(v.Value) + " isn't allowed"
+	case RuleCredentials:
+		return "credentials aren't allowed"
+	case RuleLength:
+		return "URL length " + v.Value + " exceeds limit"
+	default:
+		return "URL isn't allowed"
+	}
+}
+
+// Check validates parsed URL against policy. Returns *Violation if URL isn't allowed.
+//
+// Host name isn't resolved by Check, use Control() with net.Dialer to check resolved addresses and defeat DNS
+// rebinding.
+func (p *Policy) Check(vec *Vector) error {
+	if vec.Len() == 0 {
+		return &Violation{Rule: RuleHost}
+	}
+	if p.MaxLength > 0 {
+		if n := len(vec.Bytes()); n > p.MaxLength {
+			return &Violation{Rule: RuleLength, Value: strconv.Itoa(n)}
+		}
+	}
+	if len(p.Schemes) > 0 && !containsFold(p.Schemes, vec.SchemeBytes()) {
+		return &Violation{Rule: RuleScheme, Value: vec.SchemeString()}
+	}
+	if !p.AllowCredentials && len(vec.AuthBytes()) > 0 {
+		return &Violation{Rule: RuleCredentials}
+	}
+	if vec.opts&OptionDSN != 0 && vec.Hosts().Limit() > 1 {
+		return &Violation{Rule: RuleHost, Value: vec.HostString()}
+	}
+	if err := p.checkHost(vec.HostnameBytes()); err != nil {
+		return err
+	}
+	port := vec.getByIdx(idxPort).Bytes()
+	if _, ok := parsePort(port); len(port) > 0 && !ok {
+		return &Violation{Rule: RulePort, Value: string(port)}
+	}
+	if !p.allowPort(vec.EffectivePort()) {
+		return &Violation{Rule: RulePort, Value: strconv.Itoa(vec.EffectivePort())}
+	}
+	return nil
+}
+
+// CheckAddr validates IP address and port against policy. Returns *Violation if address isn't allowed.
+func (p *Policy) CheckAddr(addr netip.AddrPort) error {
+	if !p.allowAddr(addr.Addr()) {
+		return &Violation{Rule: RuleAddress, Value: addr.Addr().String()}
+	}
+	if !p.allowPort(int(addr.Port())) {
+		return &Violation{Rule: RulePort, Value: strconv.Itoa(int(addr.Port()))}
+	}
+	return nil
+}
+
+// Control checks resolved address before connecting. Method has signature of net.Dialer.Control and should be
+// used with it to re-check address after DNS resolution:
+//
+//	dialer := net.Dialer{Control: policy.Control}
+func (p *Policy) Control(_, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return &Violation{Rule: RuleAddress, Value: address}
+	}
+	return p.CheckAddr(addr)
+}
+
+// Check host against IP ranges and host lists.
+func (p *Policy) checkHost(hostname []byte) error {
+	if len(hostname) == 0 {
+		return &Violation{Rule: RuleHost}
+	}
+	var buf [maxHostLen + 1]byte
+	addr, isIP := parseHostAddr(hostname)
+	host := buf[:0]
+	switch {
+	case isIP:
+		if !p.allowAddr(addr) {
+			return &Violation{Rule: RuleAddress, Value: addr.String()}
+		}
+		host = addr.Unmap().WithZone("").AppendTo(host)
+	case len(hostname) > maxHostLen:
+		return &Violation{Rule: RuleHost, Value: string(hostname)}
+	default:
+		// Host matches decoded and in lower case since resolver does the same.
+		host = bufUnescape(host, hostname, modePath)
+		for i := 0; i < len(host); i++ {
+			switch c := host[i]; {
+			case c >= 'A' && c <= 'Z':
+				host[i] += 'a' - 'A'
+			case c < ' ' || c == 0x7f:
+				return &Violation{Rule: RuleHost, Value: string(hostname)}
+			}
+		}
+		host = trimDot(host)
+		// Number-like host which isn't a valid IPv4 address rejects by WHATWG URL parser.
+		if endsInNumber(host) || bytealg.IndexAnyAtBytes(host, bHostForbidden, 0) >= 0 {
+			return &Violation{Rule: RuleHost, Value: string(hostname)}
+		}
+		if !p.AllowPrivate && (bytes.Equal(host, bLocalhost) || bytes.HasSuffix(host, bLocalhostSuffix)) {
+			return &Violation{Rule: RuleAddress, Value: string(host)}
+		}
+	}
+	if matchHosts(p.DenyHosts, host) || len(p.AllowHosts) > 0 && !matchHosts(p.AllowHosts, host) {
+		return &Violation{Rule: RuleHost, Value: string(host)}
+	}
+	return nil
+}
+
+func (p *Policy) allowAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !p.AllowPrivate && !IsPublicAddr(addr) {
+		return false
+	}
+	for i := 0; i < len(p.DenyPrefixes); i++ {
+		if p.DenyPrefixes[i].Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Policy) allowPort(port int) bool {
+	if len(p.Ports) == 0 {
+		return true
+	}
+	for i := 0; i < len(p.Ports); i++ {
+		if int(p.Ports[i]) == port {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if lower case host matches any of patterns.
+func matchHosts(patterns []string, host []byte) bool {
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && pattern[0] == '*' && pattern[1] == '.' {
+			suffix := byteconv.S2B(pattern[1:])
+			if len(host) > len(suffix) && bytes.EqualFold(host[len(host)-len(suffix):], suffix) {
+				return true
+			}
+			continue
+		}
+		if len(pattern) > 0 && pattern[len(pattern)-1] == '.' {
+			pattern = pattern[:len(pattern)-1]
+		}
+		if bytes.EqualFold(host, byteconv.S2B(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if list contains p ignoring case.
+func containsFold(list []string, p []byte) bool {
+	for _, s := range list {
+		if len(s) == len(p) && bytes.EqualFold(p, byteconv.S2B(s)) {
+			return true
+		}
+	}
+	return false
+}
@@ -22,7 +22,7 @@ func (p *Pool) Get() *Vector {
 	v := p.p.Get()
 	if v != nil {
 		if vec, ok := v.(*Vector); ok {
-			vec.Helper = helper
+			vec.Helper = Helper{vec: vec}
 			return vec
 		}
 	}
@@ -0,0 +1,55 @@
+package urlvector
+
+import (
+	"errors"
+
+	"github.com/koykov/bytealg"
+)
+
+const maxPort = 65535
+
+var ErrBadPort = errors.New("invalid port")
+
+// PortValue returns port and true if URL has valid port (decimal number in range 0-65535). Returns false if port is
+// absent or invalid (http://host:abc, http://host:99999).
+func (vec *Vector) PortValue() (uint16, bool) {
+	return parsePort(vec.getByIdx(idxPort).Bytes())
+}
+
+// Check ports of all hosts in strict mode. Returns offset of invalid port on failure.
+func (vec *Vector) checkPorts(offset int) (int, error) {
+	host := vec.getByIdx(idxHost).Value()
+	raw := host.RawBytes()
+	for lo := 0; lo < len(raw); {
+		hi := len(raw)
+		if vec.opts&OptionDSN != 0 {
+			if i := bytealg.IndexByteAtBytes(raw, ',', lo); i >= 0 {
+				hi = i
+			}
+		}
+		if i := portColIdx(raw, lo, hi); i >= 0 && i+1 < hi {
+			if _, ok := parsePort(raw[i+1 : hi]); !ok {
+				return host.Offset() + i + 1, ErrBadPort
+			}
+		}
+		lo = hi + 1
+	}
+	return offset, nil
+}
+
+// Parse decimal port. Empty port is invalid.
+func parsePort(p []byte) (uint16, bool) {
+	if len(p) == 0 {
+		return 0, false
+	}
+	var port uint32
+	for i := 0; i < len(p); i++ {
+		if p[i] < '0' || p[i] > '9' {
+			return 0, false
+		}
+		if port = port*10 + uint32(p[i]-'0'); port > maxPort {
+			return 0, false
+		}
+	}
+	return uint16(port), true
+}
@@ -0,0 +1,112 @@
+package urlvector
+
+import "testing"
+
+func TestPort(t *testing.T) {
+	t.Run("value", func(t *testing.T) {
+		stages := []struct {
+			url  string
+			port uint16
+			ok   bool
+		}{
+			{"http://example.com:8080/", 8080, true},
+			{"http://example.com:0/", 0, true},
+			{"http://example.com:65535/", 65535, true},
+			{"http://example.com:080/", 80, true},
+			{"http://example.com/", 0, false},
+			{"http://example.com:/", 0, false},
+			{"http://example.com:abc/", 0, false},
+			{"http://example.com:65536/", 0, false},
+			{"http://example.com:99999999999999999999/", 0, false},
+			{"http://example.com:-1/", 0, false},
+			{"http://[::1]:443/", 443, true},
+		}
+		for _, stage := range stages {
+			vec.Reset()
+			if err := vec.ParseString(stage.url); err != nil {
+				t.Error(stage.url, err)
+				continue
+			}
+			port, ok := vec.PortValue()
+			if port != stage.port || ok != stage.ok {
+				t.Error(stage.url, "port mismatch", "need", stage.port, stage.ok, "got", port, ok)
+			}
+			if p := vec.Port(); p != int(stage.port) {
+				t.Error(stage.url, "int port mismatch", "need", stage.port, "got", p)
+			}
+		}
+	})
+	t.Run("strict", func(t *testing.T) {
+		stages := []struct {
+			url    string
+			dsn    bool
+			offset int
+		}{
+			{url: "http://example.com:8080/x", offset: -1},
+			{url: "http://example.com:/x", offset: -1},
+			{url: "http://example.com/x:abc", offset: -1},
+			{url: "http://example.com:abc/x", offset: 19},
+			{url: "http://example.com:65536", offset: 19},
+			{url: "http://[::1]:1x/", offset: 13},
+			{url: "mongodb://h1:27017,h2:27017/db", dsn: true, offset: -1},
+			{url: "mongodb://h1:27017,h2:99999/db", dsn: true, offset: 22},
+		}
+		for _, stage := range stages {
+			vec.Reset()
+			opts := OptionStrict
+			if stage.dsn {
+				opts |= OptionDSN
+			}
+			vec.SetOptions(opts)
+			err := vec.ParseString(stage.url)
+			if stage.offset < 0 {
+				if err != nil {
+					t.Error(stage.url, err)
+				}
+				continue
+			}
+			if err != ErrBadPort {
+				t.Error(stage.url, "error mismatch", "need", ErrBadPort, "got", err)
+			}
+			if off := vec.ErrorOffset(); off != stage.offset {
+				t.Error(stage.url, "error offset mismatch", "need", stage.offset, "got", off)
+			}
+		}
+	})
+	t.Run("set", func(t *testing.T) {
+		vec.Reset()
+		_ = vec.ParseString("http://example.com:8080/x")
+		bl := vec.BufLen()
+		for _, port := range []int{-1, 65536, 1 << 20} {
+			vec.SetPort(port)
+			if vec.BufLen() != bl {
+				t.Error(port, "out of range port bufferized")
+			}
+			if s := vec.String(); s != "http://example.com:8080/x" {
+				t.Error(port, "out of range port applied", "got", s)
+			}
+			bl = vec.BufLen()
+		}
+		if s := vec.SetPort(65535).String(); s != "http://example.com:65535/x" {
+			t.Error("port mismatch", "got", s)
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		vec.Reset()
+		if err := vec.ParseJSON([]byte(`{"host":"example.com","port":65536}`)); err != ErrBadJSON {
+			t.Error("error mismatch", "need", ErrBadJSON, "got", err)
+		}
+	})
+}
+
+func BenchmarkPort(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vec.Reset()
+		vec.SetOptions(OptionStrict)
+		_ = vec.ParseString("http://example.com:8080/x")
+		if port, ok := vec.PortValue(); !ok || port != 8080 {
+			b.Error("port mismatch")
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package urlvector
+
+import (
+	"bytes"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+)
+
+// RedactPolicy describes which URL parts AppendRedacted() masks.
+type RedactPolicy struct {
+	// Mask replaces sensitive values, "xxxxx" uses if empty.
+	Mask string
+	// Username enables username masking. Password masks always.
+	Username bool
+	// Keys contains names of sensitive query params. Comparison is case-insensitive, array suffix "[]" ignores.
+	Keys []string
+}
+
+const defaultRedactMask = "xxxxx"
+
+// Characters which may hide sensitive key name.
+var bRedactEsc = []byte("%+")
+
+// DefaultRedactPolicy masks password and common credential query params. It uses by Redacted() and LogValue().
+var DefaultRedactPolicy = RedactPolicy{
+	Keys: []string{
+		"token", "access_token", "refresh_token", "id_token", "auth", "code",
+		"api_key", "apikey", "key", "secret", "client_secret", "password", "passwd",
+		"signature", "sig", "x-amz-signature", "x-amz-credential", "x-amz-security-token", "x-goog-signature",
+	},
+}
+
+// Redacted returns URL with masked credentials according DefaultRedactPolicy, so it's safe to log it.
+func (vec *Vector) Redacted() string {
+	vec.out = vec.AppendRedacted(vec.out[:0], nil)
+	return string(vec.out)
+}
+
+// AppendRedacted appends URL with password, username (optionally) and values of sensitive query and fragment params
+// (#access_token=...) replaced by policy mask to dst and returns the extended buffer. Nil policy means
+// DefaultRedactPolicy. Vector keeps untouched.
+func (vec *Vector) AppendRedacted(dst []byte, policy *RedactPolicy) []byte {
+	if policy == nil {
+		policy = &DefaultRedactPolicy
+	}
+	return vec.appendComponents(dst, ComponentAll, false, policy)
+}
+
+// Get mask replacing sensitive values.
+func (rp *RedactPolicy) mask() string {
+	if len(rp.Mask) == 0 {
+		return defaultRedactMask
+	}
+	return rp.Mask
+}
+
+// Append mask to dst.
+func (rp *RedactPolicy) appendMask(dst []byte) []byte {
+	return append(dst, rp.mask()...)
+}
+
+// Append raw query to dst masking values of sensitive params.
+func (rp *RedactPolicy) appendQuery(dst, query []byte) []byte {
+	if len(query) > 0 && query[0] == '?' {
+		dst = append(dst, query[0])
+		query = query[1:]
+	}
+	return rp.appendParams(dst, query)
+}
+
+// Append fragment to dst masking values of sensitive params. Fragment may contain params directly
+// (#access_token=...&state=...) or after hash route (#/callback?code=...).
+func (rp *RedactPolicy) appendFragment(dst, hash []byte) []byte {
+	if i := bytealg.IndexByteAtBytes(hash, '?', 0); i >= 0 {
+		dst = append(dst, hash[:i+1]...)
+		return rp.appendParams(dst, hash[i+1:])
+	}
+	if len(hash) > 0 && hash[0] == '#' {
+		dst = append(dst, hash[0])
+		hash = hash[1:]
+	}
+	return rp.appendParams(dst, hash)
+}
+
+// Append key=value pairs separated by ampersand to dst masking values of sensitive params.
+func (rp *RedactPolicy) appendParams(dst, query []byte) []byte {
+	for offset := 0; offset < len(query); {
+		end := bytealg.IndexByteAtBytes(query, '&', offset)
+		if end < 0 {
+			end = len(query)
+		}
+		pair := query[offset:end]
+		if eq := bytealg.IndexByteAtBytes(pair, '=', 0); eq >= 0 && rp.sensitive(pair[:eq]) {
+			dst = append(dst, pair[:eq+1]...)
+			dst = rp.appendMask(dst)
+		} else {
+			dst = append(dst, pair...)
+		}
+		if end < len(query) {
+			dst = append(dst, bAmp...)
+		}
+		offset = end + 1
+	}
+	return dst
+}
+
+// Check if query param key is sensitive. Key compares unescaped, so escaped names (access%5Ftoken) masks as well.
+func (rp *RedactPolicy) sensitive(key []byte) bool {
+	if rp == nil {
+		return false
+	}
+	if bytealg.IndexAnyAtBytes(key, bRedactEsc, 0) >= 0 {
+		var buf [64]byte
+		key = bufUnescape(buf[:0], key, modeQuery)
+	}
+	key = bytes.TrimSuffix(key, bQB)
+	for i := 0; i < len(rp.Keys); i++ {
+		if k := rp.Keys[i]; len(k) == len(key) && bytes.EqualFold(key, byteconv.S2B(k)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+//go:build go1.21
+
+package urlvector
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer interface.
+//
+// URL logs as group of non-empty components, credentials and sensitive query values masks according
+// DefaultRedactPolicy.
+func (vec *Vector) LogValue() slog.Value {
+	if vec.Len() == 0 {
+		return slog.GroupValue()
+	}
+	rp := &DefaultRedactPolicy
+	attrs := make([]slog.Attr, 0, 8)
+	attr := func(key string, val []byte) {
+		if len(val) > 0 {
+			attrs = append(attrs, slog.String(key, string(val)))
+		}
+	}
+	attr("scheme", vec.SchemeBytes())
+	if username := vec.UsernameBytes(); len(username) > 0 && rp.Username {
+		attrs = append(attrs, slog.String("username", rp.mask()))
+	} else {
+		attr("username", username)
+	}
+	if len(vec.PasswordBytes()) > 0 {
+		attrs = append(attrs, slog.String("password", rp.mask()))
+	}
+	attr("hostname", vec.HostnameBytes())
+	attr("port", vec.getByIdx(idxPort).Bytes())
+	attr("path", vec.Path().Value().RawBytes())
+	if vec.out = vec.appendComponents(vec.out[:0], ComponentQuery, false, rp); len(vec.out) > 1 {
+		attr("query", vec.out[1:])
+	}
+	if hash := vec.HashBytes(); len(hash) > 1 {
+		vec.out = rp.appendFragment(vec.out[:0], hash[1:])
+		attr("hash", vec.out)
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,265 @@
+package urlvector
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/koykov/byteconv"
+)
+
+// TrustPolicy describes proxies trusted to provide forwarded headers (Forwarded, X-Forwarded-*).
+type TrustPolicy struct {
+	// Proxies contains trusted proxies networks. Forwarded headers ignores if request came from untrusted address.
+	Proxies []netip.Prefix
+}
+
+// NewTrustPolicy makes trust policy from list of CIDRs or single addresses.
+func NewTrustPolicy(cidrs ...string) (TrustPolicy, error) {
+	var p TrustPolicy
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return p, err
+			}
+			p.Proxies = append(p.Proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return p, err
+		}
+		p.Proxies = append(p.Proxies, prefix.Masked())
+	}
+	return p, nil
+}
+
+// Trusted checks if address belongs to trusted proxies.
+func (p TrustPolicy) Trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for i := 0; i < len(p.Proxies); i++ {
+		if p.Proxies[i].Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+var ErrBadHost = errors.New("bad host")
+
+// Forwarded header element (https://www.rfc-editor.org/rfc/rfc7239#section-4).
+type fwdElem struct {
+	for_, proto, host string
+}
+
+// ParseRequest builds URL that client actually used from HTTP request.
+//
+// Scheme, host and port takes from TLS state and Host header. If request came from trusted proxy they may be
+// overridden by Forwarded header (takes precedence) or X-Forwarded-Proto, X-Forwarded-Host, X-Forwarded-Port headers,
+// X-Forwarded-Prefix prepends to the path. Multiple proxies chain (Forwarded "for" parameters or X-Forwarded-For
+// header) walks from the nearest proxy while it's trusted, forwarded values takes from the proxy closest to the client.
+// Malformed forwarded values ignores, malformed Host header causes ErrBadHost.
+func (vec *Vector) ParseRequest(r *http.Request, trust TrustPolicy) error {
+	scheme, host, port, prefix := "http", r.Host, "", ""
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if len(host) == 0 {
+		host = r.URL.Host
+	}
+	if !validHost(host) {
+		return ErrBadHost
+	}
+
+	if trust.Trusted(remoteAddr(r.RemoteAddr)) {
+		if e, ok := forwarded(r.Header.Values("Forwarded"), trust); ok {
+			if validScheme(e.proto) {
+				scheme = e.proto
+			}
+			if len(e.host) > 0 && validHost(e.host) {
+				host = e.host
+			}
+		} else {
+			hops := forwardedHops(r.Header.Values("X-Forwarded-For"), trust)
+			if v := headerValue(r.Header, "X-Forwarded-Proto", hops); validScheme(v) {
+				scheme = v
+			}
+			if v := headerValue(r.Header, "X-Forwarded-Host", hops); len(v) > 0 && validHost(v) {
+				host = v
+			}
+			if v := headerValue(r.Header, "X-Forwarded-Port", hops); len(v) > 0 {
+				if _, ok := parsePort(byteconv.S2B(v)); ok {
+					port = v
+				}
+			}
+			if v := headerValue(r.Header, "X-Forwarded-Prefix", hops); !strings.ContainsAny(v, "?#\\") {
+				prefix = strings.TrimRight(v, "/")
+			}
+		}
+	}
+	scheme = strings.ToLower(scheme)
+
+	target := r.RequestURI
+	if len(target) == 0 {
+		target = r.URL.RequestURI()
+	}
+	if i := strings.Index(target, "://"); i > 0 && target[0] != '/' {
+		// Absolute form of request target, use path and query only.
+		target = target[i+3:]
+		if j := strings.IndexByte(target, '/'); j >= 0 {
+			target = target[j:]
+		} else {
+			target = "/"
+		}
+	}
+
+	buf := vec.tmp[:0]
+	buf = append(buf, scheme...)
+	buf = append(buf, "://"...)
+	if len(port) > 0 {
+		if i := portColIdx(byteconv.S2B(host), 0, len(host)); i >= 0 {
+			host = host[:i]
+		}
+	}
+	buf = append(buf, host...)
+	if len(port) > 0 && !isDefaultPort(scheme, port) {
+		buf = append(buf, ':')
+		buf = append(buf, port...)
+	}
+	if len(prefix) > 0 && prefix[0] != '/' {
+		buf = append(buf, '/')
+	}
+	buf = append(buf, prefix...)
+	if len(target) == 0 || target[0] != '/' {
+		buf = append(buf, '/')
+	}
+	buf = append(buf, target...)
+	vec.tmp = buf
+	return vec.parse(buf, false)
+}
+
+// Get the element of Forwarded header closest to the client through trusted proxies chain.
+func forwarded(values []string, trust TrustPolicy) (e fwdElem, ok bool) {
+	var buf [8]fwdElem
+	elems := buf[:0]
+	for _, value := range values {
+		for _, raw := range strings.Split(value, ",") {
+			var el fwdElem
+			for _, pair := range strings.Split(raw, ";") {
+				k, v, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found {
+					continue
+				}
+				v = strings.Trim(v, `"`)
+				switch strings.ToLower(k) {
+				case "for":
+					el.for_ = v
+				case "proto":
+					el.proto = v
+				case "host":
+					el.host = v
+				}
+			}
+			elems = append(elems, el)
+		}
+	}
+	if len(elems) == 0 {
+		return
+	}
+	// Each element added by the proxy that received request from "for" address, so previous element may be trusted
+	// only if "for" address is a trusted proxy.
+	i := len(elems) - 1
+	for i > 0 && trust.Trusted(remoteAddr(elems[i].for_)) {
+		i--
+	}
+	return elems[i], true
+}
+
+// Get count of trusted proxies in X-Forwarded-For chain behind the nearest one. Each proxy appends address it
+// received request from, so chain walks from the end while address is a trusted proxy.
+func forwardedHops(values []string, trust TrustPolicy) int {
+	var hops, n int
+	for i := len(values) - 1; i >= 0; i-- {
+		v := values[i]
+		for len(v) > 0 {
+			j := strings.LastIndexByte(v, ',')
+			addr := strings.TrimSpace(v[j+1:])
+			if j < 0 {
+				v = ""
+			} else {
+				v = v[:j]
+			}
+			if n++; !trust.Trusted(remoteAddr(addr)) {
+				return hops
+			}
+			hops++
+		}
+	}
+	// Whole chain is trusted, the first address is the client anyway.
+	if hops > 0 && hops == n {
+		hops--
+	}
+	return hops
+}
+
+// Get value of comma separated header added by the proxy hops positions before the nearest one. If proxies
+// overwrite header instead of appending, the first value uses.
+func headerValue(h http.Header, key string, hops int) string {
+	values := h.Values(key)
+	for i := len(values) - 1; i >= 0; i-- {
+		v := values[i]
+		for len(v) > 0 {
+			j := strings.LastIndexByte(v, ',')
+			if hops == 0 || i == 0 && j < 0 {
+				return strings.TrimSpace(v[j+1:])
+			}
+			hops--
+			if j < 0 {
+				v = ""
+			} else {
+				v = v[:j]
+			}
+		}
+	}
+	return ""
+}
+
+// Check if forwarded host doesn't contain characters which may change URL structure (path, query, user info, ...).
+func validHost(host string) bool {
+	for i := 0; i < len(host); i++ {
+		switch c := host[i]; {
+		case c <= ' ' || c == 0x7f:
+			return false
+		case c == '/' || c == '?' || c == '#' || c == '@' || c == '\\':
+			return false
+		}
+	}
+	return true
+}
+
+// Check if proto is a valid scheme.
+func validScheme(proto string) bool {
+	if len(proto) == 0 || len(proto) > maxSchemaLen {
+		return false
+	}
+	for i := 0; i < len(proto); i++ {
+		switch c := proto[i]; {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Parse address with optional port and IPv6 brackets.
+func remoteAddr(s string) netip.Addr {
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr()
+	}
+	addr, _ := netip.ParseAddr(strings.Trim(s, "[]"))
+	return addr
+}
@@ -0,0 +1,131 @@
+package urlvector
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRequest(t *testing.T) {
+	trust, err := NewTrustPolicy("10.0.0.0/8", "192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stages := []struct {
+		name, remote, target, host string
+		tls                        bool
+		headers                    map[string]string
+		url                        string
+	}{
+		{name: "direct", remote: "203.0.113.5:1234", target: "/a?x=1", host: "example.com", url: "http://example.com/a?x=1"},
+		{name: "tls", remote: "203.0.113.5:1234", target: "/a", host: "example.com:8443", tls: true, url: "https://example.com:8443/a"},
+		{
+			name: "untrusted", remote: "203.0.113.5:1234", target: "/a", host: "internal:8080",
+			headers: map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.com"},
+			url:     "http://internal:8080/a",
+		},
+		{
+			name: "x-forwarded", remote: "10.1.2.3:1234", target: "/a?x=1", host: "internal:8080",
+			headers: map[string]string{
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Host":   "example.com",
+				"X-Forwarded-Port":   "8443",
+				"X-Forwarded-Prefix": "/api/",
+			},
+			url: "https://example.com:8443/api/a?x=1",
+		},
+		{
+			name: "x-forwarded-default-port", remote: "192.168.1.1:1234", target: "/a", host: "internal:8080",
+			headers: map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "example.com", "X-Forwarded-Port": "443"},
+			url:     "https://example.com/a",
+		},
+		{
+			name: "forwarded", remote: "10.1.2.3:1234", target: "/a", host: "internal:8080",
+			headers: map[string]string{
+				"Forwarded":         `for=198.51.100.1;proto=http;host=evil.com, for="[2001:db8::1]:4711";proto=https;host=example.com, for=10.0.0.2;proto=http;host=lb.internal`,
+				"X-Forwarded-Proto": "http",
+			},
+			url: "https://example.com/a",
+		},
+		{
+			name: "x-forwarded-chain", remote: "10.1.2.3:1234", target: "/a", host: "internal:8080",
+			headers: map[string]string{
+				"X-Forwarded-For":   "203.0.113.7, 198.51.100.1, 10.0.0.5",
+				"X-Forwarded-Host":  "evil.com, example.com, lb.internal",
+				"X-Forwarded-Proto": "http, https, http",
+			},
+			url: "https://example.com/a",
+		},
+		{
+			name: "x-forwarded-overwrite", remote: "10.1.2.3:1234", target: "/a", host: "internal:8080",
+			headers: map[string]string{
+				"X-Forwarded-For":  "198.51.100.1, 10.0.0.5",
+				"X-Forwarded-Host": "example.com",
+			},
+			url: "http://example.com/a",
+		},
+		{
+			name: "x-forwarded-malformed", remote: "10.1.2.3:1234", target: "/a", host: "example.com",
+			headers: map[string]string{
+				"X-Forwarded-Host":   "evil.com/x?",
+				"X-Forwarded-Proto":  "https://evil.com/",
+				"X-Forwarded-Port":   "80/x",
+				"X-Forwarded-Prefix": "/api?x=",
+			},
+			url: "http://example.com/a",
+		},
+		{
+			name: "forwarded-malformed", remote: "10.1.2.3:1234", target: "/a", host: "example.com",
+			headers: map[string]string{"Forwarded": `for=198.51.100.1;host="user@evil.com"`},
+			url:     "http://example.com/a",
+		},
+		{
+			name: "absolute-target", remote: "203.0.113.5:1234", target: "http://example.com/a?x=1", host: "example.com",
+			url: "http://example.com/a?x=1",
+		},
+	}
+	for _, stage := range stages {
+		t.Run(stage.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", stage.target, nil)
+			r.RemoteAddr, r.Host = stage.remote, stage.host
+			if stage.tls {
+				r.TLS = &tls.ConnectionState{}
+			} else {
+				r.TLS = nil
+			}
+			for k, v := range stage.headers {
+				r.Header.Set(k, v)
+			}
+			vec.Reset()
+			if err := vec.ParseRequest(r, trust); err != nil {
+				t.Fatal(err)
+			}
+			if s := vec.String(); s != stage.url {
+				t.Error("url mismatch", "need", stage.url, "got", s)
+			}
+		})
+	}
+
+	r := httptest.NewRequest("GET", "/a", nil)
+	r.Host = "evil.com/x?"
+	vec.Reset()
+	if err := vec.ParseRequest(r, trust); err != ErrBadHost {
+		t.Error("bad host error expected", "got", err)
+	}
+}
+
+func BenchmarkParseRequest(b *testing.B) {
+	trust, _ := NewTrustPolicy("10.0.0.0/8")
+	r := httptest.NewRequest("GET", "/a?x=1", nil)
+	r.RemoteAddr, r.Host = "10.1.2.3:1234", "internal"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Host", "example.com")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vec.Reset()
+		_ = vec.ParseRequest(r, trust)
+		if h := vec.HostnameBytes(); string(h) != "example.com" {
+			b.Error("host mismatch", "need", "example.com", "got", string(h))
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package urlvector
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SchemeSpec describes scheme properties, see RegisterScheme().
+type SchemeSpec struct {
+	// DefaultPort is a port using if URL has no explicit port. Zero means no default port.
+	DefaultPort int
+	// Special marks special schemes of WHATWG URL standard (http, https, ws, wss, ftp, file).
+	// Special schemes except file have tuple origin.
+	Special bool
+	// Authority means that URL of the scheme must contain host.
+	Authority bool
+}
+
+var (
+	schemeMux sync.RWMutex
+	schemes   = map[string]SchemeSpec{
+		"http":       {DefaultPort: 80, Special: true, Authority: true},
+		"https":      {DefaultPort: 443, Special: true, Authority: true},
+		"ws":         {DefaultPort: 80, Special: true, Authority: true},
+		"wss":        {DefaultPort: 443, Special: true, Authority: true},
+		"ftp":        {DefaultPort: 21, Special: true, Authority: true},
+		"file":       {Special: true},
+		"ftps":       {DefaultPort: 990, Authority: true},
+		"sftp":       {DefaultPort: 22, Authority: true},
+		"ssh":        {DefaultPort: 22, Authority: true},
+		"git":        {DefaultPort: 9418, Authority: true},
+		"telnet":     {DefaultPort: 23, Authority: true},
+		"gopher":     {DefaultPort: 70, Authority: true},
+		"smtp":       {DefaultPort: 25, Authority: true},
+		"imap":       {DefaultPort: 143, Authority: true},
+		"imaps":      {DefaultPort: 993, Authority: true},
+		"pop3":       {DefaultPort: 110, Authority: true},
+		"ldap":       {DefaultPort: 389, Authority: true},
+		"ldaps":      {DefaultPort: 636, Authority: true},
+		"redis":      {DefaultPort: 6379, Authority: true},
+		"rediss":     {DefaultPort: 6379, Authority: true},
+		"memcached":  {DefaultPort: 11211, Authority: true},
+		"postgres":   {DefaultPort: 5432, Authority: true},
+		"postgresql": {DefaultPort: 5432, Authority: true},
+		"mysql":      {DefaultPort: 3306, Authority: true},
+		"mongodb":    {DefaultPort: 27017, Authority: true},
+		"amqp":       {DefaultPort: 5672, Authority: true},
+		"amqps":      {DefaultPort: 5671, Authority: true},
+		"mqtt":       {DefaultPort: 1883, Authority: true},
+		"mqtts":      {DefaultPort: 8883, Authority: true},
+		"nats":       {DefaultPort: 4222, Authority: true},
+		"kafka":      {DefaultPort: 9092, Authority: true},
+	}
+)
+
+// RegisterScheme adds or replaces scheme properties. Name is case-insensitive.
+func RegisterScheme(name string, spec SchemeSpec) {
+	schemeMux.Lock()
+	schemes[strings.ToLower(name)] = spec
+	schemeMux.Unlock()
+}
+
+// LookupScheme returns properties of registered scheme. Name is case-insensitive.
+func LookupScheme(name []byte) (SchemeSpec, bool) {
+	if len(name) == 0 || len(name) > maxSchemaLen {
+		return SchemeSpec{}, false
+	}
+	var buf [maxSchemaLen]byte
+	lower := appendLower(buf[:0], name)
+	schemeMux.RLock()
+	spec, ok := schemes[string(lower)]
+	schemeMux.RUnlock()
+	return spec, ok
+}
+
+// SchemeSpec returns properties of URL scheme if scheme is registered.
+func (vec *Vector) SchemeSpec() (SchemeSpec, bool) {
+	return LookupScheme(vec.SchemeBytes())
+}
+
+// SpecialScheme checks if URL has special scheme (http, https, ws, wss, ftp, file).
+func (vec *Vector) SpecialScheme() bool {
+	spec, _ := vec.SchemeSpec()
+	return spec.Special
+}
+
+// RequiresAuthority checks if URL scheme requires host.
+func (vec *Vector) RequiresAuthority() bool {
+	spec, _ := vec.SchemeSpec()
+	return spec.Authority
+}
+
+// EffectivePort returns explicit port or default port of URL scheme. Returns 0 if URL has no port and scheme has no
+// default port.
+func (vec *Vector) EffectivePort() int {
+	if vec.getByIdx(idxPort).Value().Len() > 0 {
+		return vec.Port()
+	}
+	spec, _ := vec.SchemeSpec()
+	return spec.DefaultPort
+}
+
+// ElideDefaultPort removes explicit port equal to default port of URL scheme (http://example.com:80 ->
+// http://example.com).
+func (vec *Vector) ElideDefaultPort() *Vector {
+	if vec.defaultPort() {
+		vec.dropPort()
+	}
+	return vec
+}
+
+// Check if URL has explicit port equal to default port of scheme.
+func (vec *Vector) defaultPort() bool {
+	if vec.Len() == 0 || vec.getByIdx(idxPort).Value().Len() == 0 {
+		return false
+	}
+	spec, ok := vec.SchemeSpec()
+	return ok && spec.DefaultPort > 0 && vec.Port() == spec.DefaultPort
+}
+
+// Check if port is default for lower case scheme.
+func isDefaultPort(scheme, port string) bool {
+	schemeMux.RLock()
+	spec, ok := schemes[scheme]
+	schemeMux.RUnlock()
+	if !ok || spec.DefaultPort == 0 {
+		return false
+	}
+	p, err := strconv.Atoi(port)
+	return err == nil && p == spec.DefaultPort
+}
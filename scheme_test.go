@@ -0,0 +1,69 @@
+package urlvector
+
+import "testing"
+
+func TestScheme(t *testing.T) {
+	RegisterScheme("X-Custom", SchemeSpec{DefaultPort: 7000, Authority: true})
+	stages := []struct {
+		url           string
+		port          int
+		special, auth bool
+		elided        string
+	}{
+		{"http://example.com/", 80, true, true, "http://example.com/"},
+		{"HTTPS://example.com:443/x", 443, true, true, "HTTPS://example.com/x"},
+		{"https://example.com:8443/x", 8443, true, true, "https://example.com:8443/x"},
+		{"ws://example.com:80", 80, true, true, "ws://example.com"},
+		{"wss://example.com:443/socket?a=1", 443, true, true, "wss://example.com/socket?a=1"},
+		{"ftp://example.com:21/pub", 21, true, true, "ftp://example.com/pub"},
+		{"file:///etc/hosts", 0, true, false, "file:///etc/hosts"},
+		{"redis://:secret@localhost:6379/0", 6379, false, true, "redis://:secret@localhost/0"},
+		{"postgres://db/app", 5432, false, true, "postgres://db/app"},
+		{"ssh://git@github.com:22/org/repo.git", 22, false, true, "ssh://git@github.com/org/repo.git"},
+		{"x-custom://host:7000/", 7000, false, true, "x-custom://host/"},
+		{"unknown://host:7000/", 7000, false, false, "unknown://host:7000/"},
+		{"mailto:user@example.com", 0, false, false, "mailto:user@example.com"},
+		{"//example.com:80/x", 80, false, false, "//example.com:80/x"},
+	}
+	for _, stage := range stages {
+		vec.Reset()
+		if err := vec.ParseString(stage.url); err != nil {
+			t.Error(stage.url, err)
+			continue
+		}
+		if port := vec.EffectivePort(); port != stage.port {
+			t.Error(stage.url, "effective port mismatch", "need", stage.port, "got", port)
+		}
+		if special := vec.SpecialScheme(); special != stage.special {
+			t.Error(stage.url, "special scheme mismatch", "need", stage.special, "got", special)
+		}
+		if auth := vec.RequiresAuthority(); auth != stage.auth {
+			t.Error(stage.url, "requires authority mismatch", "need", stage.auth, "got", auth)
+		}
+		if s := vec.ElideDefaultPort().String(); s != stage.elided {
+			t.Error(stage.url, "default port elision mismatch", "need", stage.elided, "got", s)
+		}
+	}
+
+	if _, ok := LookupScheme([]byte("HtTp")); !ok {
+		t.Error("case-insensitive lookup failed")
+	}
+	if _, ok := LookupScheme([]byte("x-custom")); !ok {
+		t.Error("registered scheme lookup failed")
+	}
+	if _, ok := LookupScheme([]byte("gopher-x")); ok {
+		t.Error("unknown scheme lookup must fail")
+	}
+}
+
+func BenchmarkScheme(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vec.Reset()
+		_ = vec.ParseString("HTTPS://example.com:443/x")
+		if vec.EffectivePort() != 443 || !vec.SpecialScheme() {
+			b.Error("scheme mismatch")
+		}
+		vec.ElideDefaultPort()
+	}
+}
@@ -0,0 +1,150 @@
+package urlvector
+
+import (
+	"bytes"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/vector"
+)
+
+var (
+	bSSH   = []byte("ssh")
+	bHTTPS = []byte("https")
+	bGit   = []byte("git")
+
+	bGitSuffix = []byte(".git")
+)
+
+// ToSSH rewrites git remote in place to ssh://user@host[:port]/path form.
+//
+// Username defaults to "git", password drops. Port keeps only for ssh remotes with non-default port.
+func (vec *Vector) ToSSH() *Vector {
+	if !bytes.EqualFold(vec.SchemeBytes(), bSSH) || vec.Port() == 22 {
+		vec.dropPort()
+	}
+	if len(vec.UsernameBytes()) == 0 {
+		vec.set(vec.Username(), bGit)
+	}
+	vec.set(vec.Auth(), vec.UsernameBytes())
+	vec.set(vec.Password(), nil)
+	return vec.toRemote(bSSH)
+}
+
+// ToHTTPS rewrites git remote in place to https://host[:port]/path form.
+//
+// Username and password drops. Port keeps only for https remotes with non-default port.
+func (vec *Vector) ToHTTPS() *Vector {
+	if !bytes.EqualFold(vec.SchemeBytes(), bHTTPS) || vec.Port() == 443 {
+		vec.dropPort()
+	}
+	vec.set(vec.Auth(), nil)
+	vec.set(vec.Username(), nil)
+	vec.set(vec.Password(), nil)
+	return vec.toRemote(bHTTPS)
+}
+
+// SameRepo checks if both git remotes point to the same repository regardless of remote form (scp-like, ssh, https).
+//
+// Hosts compare ignoring case, paths compare ignoring leading and trailing slashes and ".git" suffix. Scheme, user info
+// and port are ignored since they depend on transport.
+func (vec *Vector) SameRepo(other *Vector) bool {
+	if vec.Len() == 0 || other.Len() == 0 {
+		return false
+	}
+	return bytes.EqualFold(trimDot(vec.HostnameBytes()), trimDot(other.HostnameBytes())) &&
+		bytes.Equal(repoPath(vec.Path().Value().RawBytes()), repoPath(other.Path().Value().RawBytes()))
+}
+
+// Trim slashes and ".git" suffix of repository path.
+func repoPath(path []byte) []byte {
+	path = bytes.Trim(path, "/")
+	path = bytes.TrimSuffix(path, bGitSuffix)
+	return bytes.TrimRight(path, "/")
+}
+
+// Replace scheme and make path absolute.
+func (vec *Vector) toRemote(scheme []byte) *Vector {
+	if path := vec.Path().Value().RawBytes(); len(path) == 0 || path[0] != '/' {
+		offset := vec.BufLen()
+		vec.Bufferize(bSlash)
+		vec.Bufferize(path)
+		vec.setTail(vec.Path(), offset)
+	}
+	vec.SetBit(flagSCP, false)
+	return vec.set(vec.Scheme(), scheme)
+}
+
+// Remove port from host.
+func (vec *Vector) dropPort() {
+	vec.resetHosts()
+	vec.set(vec.getByIdx(idxPort), nil)
+	vec.set(vec.Host(), vec.HostnameBytes())
+}
+
+// Parse authority of scp-like remote ([user@]host:path).
+func (vec *Vector) parseSCP(depth, offset int, node *vector.Node) int {
+	auth, ia := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	username, iu := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	password, ip := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	host, ih := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	hostname, in := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	port, ipt := vec.AcquireChildWithType(node, depth, vector.TypeNumber)
+
+	src := vec.Src()
+	posCol := scpColIdx(src)
+	if posAt := bytealg.IndexByteAtBytes(src[:posCol], '@', offset); posAt >= 0 {
+		auth.Key().Init(bKeys, offsetAuth, lenAuth)
+		auth.Value().Init(src, offset, posAt-offset)
+		username.Key().Init(bKeys, offsetUsername, lenUsername)
+		username.Value().Init(src, offset, posAt-offset)
+		offset = posAt + 1
+	}
+	host.Key().Init(bKeys, offsetHost, lenHost)
+	host.Value().Init(src, offset, posCol-offset)
+	hostname.Key().Init(bKeys, offsetHostname, lenHostname)
+	hostname.Value().Init(src, offset, posCol-offset)
+
+	vec.relNode(ia, auth)
+	vec.relNode(iu, username)
+	vec.relNode(ip, password)
+	vec.relNode(ih, host)
+	vec.relNode(in, hostname)
+	vec.relNode(ipt, port)
+
+	return posCol + 1
+}
+
+// Get position of colon separating host and path of scp-like remote or -1 if src isn't a scp-like remote.
+//
+// Similar to git, colon must precede any slash, and single letter host considers as a drive letter of local path.
+// All-digit path head considers as a port (localhost:8080/path).
+func scpColIdx(src []byte) int {
+	var lo int
+	for i := 0; i < len(src); i++ {
+		switch src[i] {
+		case '@':
+			lo = i + 1
+		case '[':
+			if j := bytealg.IndexByteAtBytes(src, ']', i); j > 0 {
+				i = j
+			}
+		case ':':
+			if i-lo < 2 || bytes.HasPrefix(src[i+1:], bSlashes) || isPortHead(src[i+1:]) {
+				return -1
+			}
+			return i
+		case '/', '?', '#':
+			return -1
+		}
+	}
+	return -1
+}
+
+// Check if p starts with digits followed by slash or end of p.
+func isPortHead(p []byte) bool {
+	i := bytealg.IndexAnyAtBytes(p, bAuthorityEnd, 0)
+	if i < 0 {
+		i = len(p)
+	}
+	return i > 0 && isDigits(p[:i])
+}
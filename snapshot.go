@@ -0,0 +1,364 @@
+package urlvector
+
+import (
+	"encoding/binary"
+	"errors"
+	"unsafe"
+
+	"github.com/koykov/vector"
+)
+
+// Snapshot format:
+//
+//	magic "UVS", version byte
+//	uvarint vector flags, uvarint options
+//	uvarint buffer length, buffer bytes
+//	uvarint source offset in buffer, uvarint source length
+//	uvarint index depth, uvarint nodes count
+//	per depth: uvarint row length, uvarint node indexes
+//	per node: uvarint depth, type, offset, limit, key reference, value reference
+//
+// Reference is a region byte, flags byte and, if region isn't empty, uvarint offset and length within the region.
+// Source is always a part of buffer section, so nodes keeps the same offsets after loading.
+const (
+	snapshotVersion = 1
+	// Max depth of index rows.
+	snapshotDepth = 16
+)
+
+// Regions of byteptr references.
+const (
+	regionNone = iota
+	regionSrc
+	regionBuf
+	regionKeys
+)
+
+var (
+	bSnapshotMagic = []byte("UVS")
+
+	ErrBadSnapshot     = errors.New("malformed snapshot")
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+)
+
+// AppendSnapshot appends binary snapshot of parsed vector to dst and returns the extended buffer.
+//
+// Snapshot contains source, buffer and nodes index, see LoadSnapshot().
+func (vec *Vector) AppendSnapshot(dst []byte) []byte {
+	dst = append(dst, bSnapshotMagic...)
+	dst = append(dst, snapshotVersion)
+	var flags uint64
+	for i := flagCopy; i < 64; i++ {
+		if vec.CheckBit(i) {
+			flags |= 1 << uint(i-flagCopy)
+		}
+	}
+	dst = appendUvarint(dst, flags)
+	dst = appendUvarint(dst, uint64(vec.opts))
+
+	// Buffer section: buffer itself, source if it's outside of buffer, bytes of nodes pointing to foreign memory.
+	buf, src := vec.Buf(), vec.Src()
+	srcOff, tail := ptrOffset(buf, src), len(buf)
+	if srcOff < 0 {
+		srcOff = len(buf)
+		tail += len(src)
+	}
+	extra := tail - len(buf)
+	n := vec.Len()
+	for i := 0; i < n; i++ {
+		node := vec.nodeAt(i)
+		extra += vec.foreignLen(node.Key()) + vec.foreignLen(node.Value())
+	}
+	dst = appendUvarint(dst, uint64(len(buf)+extra))
+	dst = append(dst, buf...)
+	if tail > len(buf) {
+		dst = append(dst, src...)
+	}
+	for i := 0; i < n; i++ {
+		node := vec.nodeAt(i)
+		if vec.foreignLen(node.Key()) > 0 {
+			dst = append(dst, node.Key().RawBytes()...)
+		}
+		if vec.foreignLen(node.Value()) > 0 {
+			dst = append(dst, node.Value().RawBytes()...)
+		}
+	}
+	dst = appendUvarint(dst, uint64(srcOff))
+	dst = appendUvarint(dst, uint64(len(src)))
+
+	depth := 0
+	for i := 0; i < n; i++ {
+		if d := vec.GetByIdx(i).Depth() + 2; d > depth {
+			depth = d
+		}
+	}
+	dst = appendUvarint(dst, uint64(depth))
+	dst = appendUvarint(dst, uint64(n))
+	for i := 0; i < depth; i++ {
+		row := vec.Index.GetRow(i)
+		dst = appendUvarint(dst, uint64(len(row)))
+		for j := 0; j < len(row); j++ {
+			dst = appendUvarint(dst, uint64(row[j]))
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		node := vec.nodeAt(i)
+		dst = appendUvarint(dst, uint64(node.Depth()))
+		dst = appendUvarint(dst, uint64(node.Type()))
+		dst = appendUvarint(dst, uint64(node.Offset()))
+		dst = appendUvarint(dst, uint64(node.Limit()))
+		dst, tail = vec.appendRef(dst, node.Key(), tail)
+		dst, tail = vec.appendRef(dst, node.Value(), tail)
+	}
+	return dst
+}
+
+// LoadSnapshot restores vector from snapshot made by AppendSnapshot() without parsing.
+//
+// Snapshot data copies to the vector buffer and may be reused after the call. Vector must be reset before the call,
+// otherwise ErrNotReset returns. Malformed snapshot keeps vector untouched.
+func (vec *Vector) LoadSnapshot(p []byte) error {
+	if !vec.CheckBit(vector.FlagInit) {
+		return errBadInit
+	}
+	if vec.Len() > 0 {
+		return ErrNotReset
+	}
+	if len(p) < len(bSnapshotMagic)+1 || string(p[:len(bSnapshotMagic)]) != string(bSnapshotMagic) {
+		return ErrBadSnapshot
+	}
+	if p[len(bSnapshotMagic)] != snapshotVersion {
+		return ErrSnapshotVersion
+	}
+	r := snapshotReader{p: p, off: len(bSnapshotMagic) + 1}
+
+	flags, opts := r.uvarint(), r.uvarint()
+	buf := r.bytes(r.uvarint())
+	srcOff, srcLen := r.uvarint(), r.uvarint()
+	if r.err != nil || srcOff > uint64(len(buf)) || srcLen > uint64(len(buf))-srcOff {
+		return ErrBadSnapshot
+	}
+
+	depth, n := r.uvarint(), r.uvarint()
+	// Parsed vector contains at least root and fixed URL parts.
+	if r.err != nil || depth > snapshotDepth || n > uint64(len(p)) || n > 0 && n <= idxHosts {
+		return ErrBadSnapshot
+	}
+	// Check index rows and remember its lengths, rows registers after nodes.
+	var rows [snapshotDepth]uint64
+	rowsOff := r.off
+	for i := uint64(0); i < depth; i++ {
+		rows[i] = r.uvarint()
+		for j := uint64(0); j < rows[i] && r.err == nil; j++ {
+			if r.uvarint() >= n {
+				return ErrBadSnapshot
+			}
+		}
+	}
+	if r.err != nil {
+		return ErrBadSnapshot
+	}
+
+	// Check node records before any change of the vector, depths of nodes keeps in temporary buffer to check rows.
+	nodesOff := r.off
+	depths := vec.tmp[:0]
+	for i := uint64(0); i < n; i++ {
+		d, _, off, lim := r.uvarint(), r.uvarint(), r.uvarint(), r.uvarint()
+		if r.err != nil || d+1 >= depth || lim > 0 && (off > rows[d+1] || lim > rows[d+1]-off) {
+			return ErrBadSnapshot
+		}
+		r.checkRef(len(buf), int(srcLen))
+		r.checkRef(len(buf), int(srcLen))
+		depths = append(depths, byte(d))
+	}
+	vec.tmp = depths
+	if r.err != nil || r.off != len(p) {
+		return ErrBadSnapshot
+	}
+	// Row contains nodes of its depth only, so children are always deeper than parent.
+	r.off = rowsOff
+	for i := uint64(0); i < depth; i++ {
+		l := r.uvarint()
+		for j := uint64(0); j < l; j++ {
+			if uint64(depths[r.uvarint()]) != i {
+				return ErrBadSnapshot
+			}
+		}
+	}
+
+	vec.touch()
+	vec.BufReplaceWith(append(vec.Buf()[:0], buf...))
+	if srcLen > 0 {
+		_ = vec.SetSrc(vec.Buf()[srcOff:srcOff+srcLen], false)
+	}
+	for i := 0; i < 64-flagCopy; i++ {
+		vec.SetBit(i+flagCopy, flags&(1<<uint(i)) != 0)
+	}
+	vec.opts = Option(opts)
+
+	r.off = nodesOff
+	for i := uint64(0); i < n; i++ {
+		d, typ, off, lim := r.uvarint(), r.uvarint(), r.uvarint(), r.uvarint()
+		node, j := vec.AcquireNodeWithType(int(d), vector.Type(typ))
+		node.SetOffset(int(off))
+		node.SetLimit(int(lim))
+		r.ref(vec, node.Key())
+		r.ref(vec, node.Value())
+		vec.ReleaseNode(j, node)
+	}
+	r.off = rowsOff
+	for i := 0; i < int(depth); i++ {
+		l := r.uvarint()
+		for j := uint64(0); j < l; j++ {
+			vec.Index.Register(i, int(r.uvarint()))
+		}
+	}
+	return nil
+}
+
+// Get length of bytes pointing to memory outside of source, buffer and keys.
+func (vec *Vector) foreignLen(p *vector.Byteptr) int {
+	raw := p.RawBytes()
+	if len(raw) == 0 || ptrOffset(vec.Src(), raw) >= 0 || ptrOffset(vec.Buf(), raw) >= 0 || ptrOffset(bKeys, raw) >= 0 {
+		return 0
+	}
+	return len(raw)
+}
+
+// Append byteptr reference to dst. Foreign bytes address to the tail of buffer section.
+func (vec *Vector) appendRef(dst []byte, p *vector.Byteptr, tail int) ([]byte, int) {
+	var flags byte
+	for i := 0; i < 8; i++ {
+		if p.CheckBit(flagEscape + i) {
+			flags |= 1 << uint(i)
+		}
+	}
+	raw := p.RawBytes()
+	region, off := byte(regionNone), 0
+	if len(raw) > 0 {
+		// Buffer sourced bytes re-addresses relative to the buffer, so the source region (may be placed in the buffer)
+		// mustn't be used for them.
+		if off = ptrOffset(vec.Src(), raw); off >= 0 && !p.CheckBit(flagBufSrc) {
+			region = regionSrc
+		} else if off = ptrOffset(vec.Buf(), raw); off >= 0 {
+			region = regionBuf
+		} else if off = ptrOffset(bKeys, raw); off >= 0 {
+			region = regionKeys
+		} else {
+			region, off = regionBuf, tail
+			tail += len(raw)
+		}
+	}
+	dst = append(dst, region, flags)
+	if region != regionNone {
+		dst = appendUvarint(dst, uint64(off))
+		dst = appendUvarint(dst, uint64(len(raw)))
+	}
+	return dst, tail
+}
+
+// Sequential snapshot decoder. First error stops reading.
+type snapshotReader struct {
+	p   []byte
+	off int
+	err error
+}
+
+func (r *snapshotReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(r.p[r.off:])
+	if n <= 0 {
+		r.err = ErrBadSnapshot
+		return 0
+	}
+	r.off += n
+	return x
+}
+
+func (r *snapshotReader) bytes(n uint64) []byte {
+	if r.err != nil || n > uint64(len(r.p)-r.off) {
+		r.err = ErrBadSnapshot
+		return nil
+	}
+	p := r.p[r.off : r.off+int(n)]
+	r.off += int(n)
+	return p
+}
+
+// Read byteptr reference and check it fits its region of given buffer and source lengths.
+func (r *snapshotReader) checkRef(bufLen, srcLen int) {
+	region, flags, off, l := r.readRef()
+	if r.err != nil || region == regionNone {
+		return
+	}
+	var size int
+	switch region {
+	case regionSrc:
+		size = srcLen
+	case regionBuf:
+		size = bufLen
+	case regionKeys:
+		size = len(bKeys)
+	default:
+		r.err = ErrBadSnapshot
+		return
+	}
+	if off > uint64(size) || l > uint64(size)-off {
+		r.err = ErrBadSnapshot
+		return
+	}
+	// Buffer sourced bytes re-addresses to the buffer by demand, so they must fit it too.
+	if flags&(1<<uint(flagBufSrc-flagEscape)) != 0 && (region == regionKeys || off+l > uint64(bufLen)) {
+		r.err = ErrBadSnapshot
+	}
+}
+
+// Read byteptr reference checked by checkRef() and init p.
+func (r *snapshotReader) ref(vec *Vector, p *vector.Byteptr) {
+	region, flags, off, l := r.readRef()
+	switch region {
+	case regionSrc:
+		p.Init(vec.Src(), int(off), int(l))
+	case regionBuf:
+		p.Init(vec.Buf(), int(off), int(l))
+	case regionKeys:
+		p.Init(bKeys, int(off), int(l))
+	}
+	for i := 0; i < 8; i++ {
+		p.SetBit(flagEscape+i, flags&(1<<uint(i)) != 0)
+	}
+}
+
+// Read region, flags and, if region isn't empty, offset and length of byteptr reference.
+func (r *snapshotReader) readRef() (region, flags byte, off, l uint64) {
+	b := r.bytes(2)
+	if r.err != nil {
+		return
+	}
+	region, flags = b[0], b[1]
+	if region != regionNone {
+		off, l = r.uvarint(), r.uvarint()
+	}
+	return
+}
+
+// Get offset of p in region or -1 if p points outside of region.
+func ptrOffset(region, p []byte) int {
+	if len(region) == 0 || len(p) == 0 {
+		return -1
+	}
+	lo, x := uintptr(unsafe.Pointer(&region[0])), uintptr(unsafe.Pointer(&p[0]))
+	if x < lo || x+uintptr(len(p)) > lo+uintptr(len(region)) {
+		return -1
+	}
+	return int(x - lo)
+}
+
+func appendUvarint(dst []byte, x uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], x)
+	return append(dst, b[:n]...)
+}
@@ -0,0 +1,167 @@
+package urlvector
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/koykov/bytealg"
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+// TargetForm represents form of HTTP request target, see https://www.rfc-editor.org/rfc/rfc9112#section-3.2.
+type TargetForm int
+
+const (
+	// TargetFormOrigin is an absolute path with optional query (/path?x=1).
+	TargetFormOrigin TargetForm = iota
+	// TargetFormAbsolute is an absolute URI (http://host/path).
+	TargetFormAbsolute
+	// TargetFormAuthority is a host and port of CONNECT request (host:443).
+	TargetFormAuthority
+	// TargetFormAsterisk is a server-wide OPTIONS request target (*).
+	TargetFormAsterisk
+)
+
+var (
+	bConnect  = []byte("CONNECT")
+	bOptions  = []byte("OPTIONS")
+	bAsterisk = []byte("*")
+
+	ErrBadTarget = errors.New("bad request target")
+)
+
+// ParseRequestTarget parses request target of given method considering its form.
+func (vec *Vector) ParseRequestTarget(method, target []byte) error {
+	form, ok := targetForm(method, target)
+	if !ok {
+		return ErrBadTarget
+	}
+	if form == TargetFormAbsolute {
+		if err := vec.parse(target, false); err != nil {
+			return err
+		}
+		if len(vec.SchemeBytes()) == 0 {
+			return ErrBadTarget
+		}
+		return nil
+	}
+	return vec.parseTarget(target, form)
+}
+
+// ParseRequestTargetString parses request target string of given method considering its form.
+func (vec *Vector) ParseRequestTargetString(method, target string) error {
+	return vec.ParseRequestTarget(byteconv.S2B(method), byteconv.S2B(target))
+}
+
+// TargetForm returns request target form of the URL.
+func (vec *Vector) TargetForm() TargetForm {
+	switch {
+	case len(vec.SchemeBytes()) > 0:
+		return TargetFormAbsolute
+	case len(vec.HostnameBytes()) > 0:
+		return TargetFormAuthority
+	case bytes.Equal(vec.Path().Value().RawBytes(), bAsterisk):
+		return TargetFormAsterisk
+	}
+	return TargetFormOrigin
+}
+
+// SetOriginForm converts URL to origin form (path and query) to forward it to the origin server.
+//
+// Scheme, authority and hash drops, empty path replaces with "/".
+func (vec *Vector) SetOriginForm() *Vector {
+	vec.SetBit(flagOpaque, false)
+	vec.SetBit(flagFile, false)
+	vec.SetBit(flagSCP, false)
+	vec.resetOpaque()
+	vec.resetHosts()
+	vec.resetFragment()
+	vec.set(vec.Scheme(), nil)
+	vec.set(vec.getByIdx(idxSlashes), nil)
+	vec.set(vec.Auth(), nil)
+	vec.set(vec.Username(), nil)
+	vec.set(vec.Password(), nil)
+	vec.set(vec.Host(), nil)
+	vec.set(vec.Hostname(), nil)
+	vec.set(vec.getByIdx(idxPort), nil)
+	vec.set(vec.Hash(), nil)
+	if vec.Path().Value().Len() == 0 {
+		vec.set(vec.Path(), bSlash)
+	}
+	return vec
+}
+
+// Parse request target of origin, authority or asterisk form.
+func (vec *Vector) parseTarget(s []byte, form TargetForm) (err error) {
+	if !vec.CheckBit(vector.FlagInit) {
+		err = errBadInit
+		return
+	}
+	vec.touch()
+	if err = vec.SetSrc(s, false); err != nil {
+		return
+	}
+	vec.SetBit(flagCopy, false)
+
+	offset := 0
+	root, i := vec.AcquireNodeWithType(0, vector.TypeObject)
+	root.SetOffset(vec.Index.Len(1))
+
+	vec.skipScheme(1, root)
+	// Asterisk has no leading slash, so consider it as an opaque path.
+	vec.SetBit(flagOpaque, form == TargetFormAsterisk)
+	if form == TargetFormAuthority {
+		if offset, err = vec.parseAuthority(1, offset, root); err != nil {
+			vec.SetErrOffset(offset)
+			return
+		}
+	} else {
+		vec.skipAuthority(1, root)
+	}
+	if offset, err = vec.parsePath(1, offset, root); err != nil {
+		vec.SetErrOffset(offset)
+		return
+	}
+	if offset, err = vec.parseQuery(1, offset, root); err != nil {
+		vec.SetErrOffset(offset)
+		return
+	}
+
+	vec.relNode(i, root)
+
+	if offset < vec.SrcLen() {
+		vec.SetErrOffset(offset)
+		err = vector.ErrUnparsedTail
+	}
+	return
+}
+
+// Register empty scheme and slashes nodes for URLs without scheme.
+func (vec *Vector) skipScheme(depth int, node *vector.Node) {
+	scheme, isc := vec.AcquireChildWithType(node, depth, vector.TypeString)
+	slashes, isl := vec.AcquireChildWithType(node, depth, vector.TypeBool)
+	vec.SetBit(flagOpaque, false)
+	vec.SetBit(flagFile, false)
+	vec.SetBit(flagSCP, false)
+	vec.relNode(isc, scheme)
+	vec.relNode(isl, slashes)
+}
+
+// Get form of request target considering request method.
+func targetForm(method, target []byte) (TargetForm, bool) {
+	switch {
+	case len(target) == 0:
+		return 0, false
+	case bytes.EqualFold(method, bConnect):
+		// Authority form contains only host and port.
+		ok := bytealg.IndexAnyAtBytes(target, bAuthorityEnd, 0) < 0 && bytealg.IndexByteAtBytes(target, '@', 0) < 0 &&
+			portColIdx(target, 0, len(target)) > 0
+		return TargetFormAuthority, ok
+	case bytes.Equal(target, bAsterisk):
+		return TargetFormAsterisk, bytes.EqualFold(method, bOptions)
+	case target[0] == '/':
+		return TargetFormOrigin, bytealg.IndexByteAtBytes(target, '#', 0) < 0
+	}
+	return TargetFormAbsolute, true
+}
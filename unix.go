@@ -0,0 +1,74 @@
+package urlvector
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/koykov/byteconv"
+)
+
+var (
+	bUnix       = []byte("unix")
+	bUnixSuffix = []byte("+unix")
+
+	ErrNoPort = errors.New("no port to dial")
+)
+
+// IsUnix checks if URL points to unix socket (unix:///var/run/docker.sock or http+unix://%2Fvar%2Frun%2Fapp.sock/path).
+func (vec *Vector) IsUnix() bool {
+	scheme := vec.SchemeBytes()
+	return bytes.EqualFold(scheme, bUnix) ||
+		(len(scheme) > len(bUnixSuffix) && bytes.EqualFold(scheme[len(scheme)-len(bUnixSuffix):], bUnixSuffix))
+}
+
+// SocketPathBytes returns unescaped unix socket path.
+//
+// Socket path takes from the path for unix scheme and from the host for transport schemes (http+unix, ...), in that
+// case Path() contains request path. Result uses internal buffer.
+func (vec *Vector) SocketPathBytes() []byte {
+	if !vec.IsUnix() {
+		return nil
+	}
+	offset := vec.BufLen()
+	vec.BufReplaceWith(vec.appendSocketPath(vec.Buf()))
+	vec.SetBit(flagBufMod, true)
+	return vec.Buf()[offset:]
+}
+
+// SocketPathString returns unescaped unix socket path.
+func (vec *Vector) SocketPathString() string {
+	return byteconv.B2S(vec.SocketPathBytes())
+}
+
+// DialArgs returns network and address arguments ready to use in net.Dial().
+//
+// Unix socket URLs produces "unix" network and socket path, other URLs - "tcp" network and host:port address. Missing
+// port replaces with default port of scheme (see RegisterScheme()), ErrNoPort returns if scheme has no default one.
+// Address is a copy and stays valid after the vector changes.
+func (vec *Vector) DialArgs() (network, address string, err error) {
+	if vec.IsUnix() {
+		vec.out = vec.appendSocketPath(vec.out[:0])
+		return "unix", string(vec.out), nil
+	}
+	dst := append(vec.out[:0], vec.HostnameBytes()...)
+	if port := vec.getByIdx(idxPort).Bytes(); len(port) > 0 {
+		dst = append(dst, bColon...)
+		dst = append(dst, port...)
+	} else if port := vec.EffectivePort(); port > 0 {
+		dst = append(dst, bColon...)
+		dst = strconv.AppendInt(dst, int64(port), 10)
+	} else {
+		return "", "", ErrNoPort
+	}
+	vec.out = dst
+	return "tcp", string(dst), nil
+}
+
+// Append unescaped socket path to dst.
+func (vec *Vector) appendSocketPath(dst []byte) []byte {
+	if bytes.EqualFold(vec.SchemeBytes(), bUnix) {
+		return append(dst, vec.PathBytes()...)
+	}
+	return bufUnescape(dst, vec.HostBytes(), modePath)
+}
@@ -0,0 +1,71 @@
+package urlvector
+
+import (
+	"testing"
+)
+
+func TestUnix(t *testing.T) {
+	stages := []struct {
+		url, socket, path, network, address string
+	}{
+		{"unix:///var/run/docker.sock", "/var/run/docker.sock", "/var/run/docker.sock", "unix", "/var/run/docker.sock"},
+		{"unix:///tmp/my%20app%231.sock", "/tmp/my app#1.sock", "/tmp/my app#1.sock", "unix", "/tmp/my app#1.sock"},
+		{"http+unix://%2Fvar%2Frun%2Fapp.sock/v1/status", "/var/run/app.sock", "/v1/status", "unix", "/var/run/app.sock"},
+		{"https://example.com/v1", "", "/v1", "tcp", "example.com:443"},
+		{"http://example.com:8080/v1", "", "/v1", "tcp", "example.com:8080"},
+		{"redis://cache.local/0", "", "/0", "tcp", "cache.local:6379"},
+		{"http://[::1]/", "", "/", "tcp", "[::1]:80"},
+		{"foo://example.com:9000/x", "", "/x", "tcp", "example.com:9000"},
+	}
+	for _, stage := range stages {
+		vec.Reset()
+		if err := vec.ParseString(stage.url); err != nil {
+			t.Error(stage.url, err)
+			continue
+		}
+		if s := vec.SocketPathString(); s != stage.socket {
+			t.Error(stage.url, "socket path mismatch", "need", stage.socket, "got", s)
+		}
+		if p := vec.PathString(); p != stage.path {
+			t.Error(stage.url, "path mismatch", "need", stage.path, "got", p)
+		}
+		network, address, err := vec.DialArgs()
+		if err != nil {
+			t.Error(stage.url, err)
+		}
+		if network != stage.network || address != stage.address {
+			t.Error(stage.url, "dial args mismatch", "need", stage.network, stage.address, "got", network, address)
+		}
+		if s := vec.String(); s != stage.url {
+			t.Error(stage.url, "url assembly failed", "got", s)
+		}
+	}
+
+	vec.Reset()
+	_ = vec.ParseString("foo://example.com/x")
+	if network, address, err := vec.DialArgs(); err != ErrNoPort || network != "" || address != "" {
+		t.Error("error expected without port", "got", network, address, err)
+	}
+
+	vec.Reset()
+	_ = vec.ParseString("https://example.com/v1")
+	_, address, _ := vec.DialArgs()
+	vec.SetHostnameString("other.example.com")
+	_ = vec.String()
+	_, _, _ = vec.DialArgs()
+	if address != "example.com:443" {
+		t.Error("dial address must outlive vector changes", "got", address)
+	}
+}
+
+func BenchmarkUnix(b *testing.B) {
+	src := "http+unix://%2Fvar%2Frun%2Fapp.sock/v1/status"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vec.Reset()
+		_ = vec.ParseString(src)
+		if s := vec.SocketPathBytes(); string(s) != "/var/run/app.sock" {
+			b.Error("socket path mismatch", "need", "/var/run/app.sock", "got", string(s))
+		}
+	}
+}
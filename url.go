@@ -0,0 +1,152 @@
+package urlvector
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/koykov/byteconv"
+	"github.com/koykov/vector"
+)
+
+var ErrNotReset = errors.New("vector must be reset before the call")
+
+// ToURL fills u with URL parts considering net/url semantics: path, user info and fragment are unescaped, raw versions
+// sets to RawPath and RawFragment only if they differ from default escaping.
+func (vec *Vector) ToURL(u *url.URL) {
+	*u = url.URL{}
+	u.Scheme = strings.ToLower(vec.SchemeString())
+
+	raw := vec.Path().Value().RawBytes()
+	if vec.CheckBit(flagOpaque) {
+		u.Opaque = string(raw)
+	} else {
+		u.Path = string(vec.PathBytes())
+		if u.EscapedPath() != byteconv.B2S(raw) {
+			u.RawPath = string(raw)
+		}
+	}
+
+	if username, password := vec.UsernameBytes(), vec.PasswordBytes(); len(username) > 0 || len(password) > 0 {
+		name, _ := url.PathUnescape(byteconv.B2S(username))
+		if len(password) > 0 {
+			pass, _ := url.PathUnescape(byteconv.B2S(password))
+			u.User = url.UserPassword(name, pass)
+		} else {
+			u.User = url.User(name)
+		}
+	}
+	u.Host = vec.HostString()
+
+	if query := vec.QueryBytes(); len(query) > 0 {
+		query = query[1:]
+		u.RawQuery = string(query)
+		u.ForceQuery = len(query) == 0
+	}
+
+	if hash := vec.HashBytes(); len(hash) > 1 {
+		rawFrag := byteconv.B2S(hash[1:])
+		frag, err := url.PathUnescape(rawFrag)
+		if err != nil {
+			frag = rawFrag
+		}
+		u.Fragment = frag
+		if u.EscapedFragment() != rawFrag {
+			u.RawFragment = rawFrag
+		}
+	}
+}
+
+// FromURL populates vector from u without serialization to string.
+//
+// Path keeps escaped as u.EscapedPath() returns. Vector must be reset before the call, otherwise ErrNotReset returns.
+func (vec *Vector) FromURL(u *url.URL) error {
+	if !vec.CheckBit(vector.FlagInit) {
+		return errBadInit
+	}
+	if vec.Len() > 0 {
+		return ErrNotReset
+	}
+	vec.touch()
+	vec.SetBit(flagCopy, false)
+	vec.SetBit(flagOpaque, len(u.Opaque) > 0)
+	vec.SetBit(flagFile, strings.EqualFold(u.Scheme, "file"))
+	vec.SetBit(flagSCP, false)
+
+	root, i := vec.AcquireNodeWithType(0, vector.TypeObject)
+	root.SetOffset(vec.Index.Len(1))
+
+	vec.fromString(root, vector.TypeString, offsetScheme, lenScheme, u.Scheme)
+	slashes, is := vec.AcquireChildWithType(root, 1, vector.TypeBool)
+	if len(u.Scheme) == 0 && len(u.Host) > 0 {
+		slashes.Key().Init(bKeys, offsetSlashes, lenSlashes)
+		slashes.Value().Init(bKeys, offsetTrue, lenTrue)
+	}
+	vec.ReleaseNode(is, slashes)
+
+	var auth, username, password string
+	if u.User != nil {
+		auth = u.User.String()
+		username = auth
+		if j := strings.IndexByte(username, ':'); j >= 0 {
+			username, password = username[:j], username[j+1:]
+		}
+	}
+	vec.fromString(root, vector.TypeString, offsetAuth, lenAuth, auth)
+	vec.fromString(root, vector.TypeString, offsetUsername, lenUsername, username)
+	vec.fromString(root, vector.TypeString, offsetPassword, lenPassword, password)
+
+	hostname, port := u.Host, ""
+	if j := portColIdx(byteconv.S2B(u.Host), 0, len(u.Host)); j >= 0 {
+		hostname, port = u.Host[:j], u.Host[j+1:]
+	}
+	vec.fromString(root, vector.TypeString, offsetHost, lenHost, u.Host)
+	vec.fromString(root, vector.TypeString, offsetHostname, lenHostname, hostname)
+	vec.fromString(root, vector.TypeNumber, offsetPort, lenPort, port)
+
+	if len(u.Opaque) > 0 {
+		vec.fromString(root, vector.TypeString, offsetPath, lenPath, u.Opaque)
+	} else {
+		escPath := u.EscapedPath()
+		path := vec.fromString(root, vector.TypeString, offsetPath, lenPath, escPath)
+		path.Value().SetBit(flagEscape, strings.IndexByte(escPath, '%') >= 0)
+	}
+
+	offset := vec.BufLen()
+	if len(u.RawQuery) > 0 || u.ForceQuery {
+		vec.Bufferize(bQM)
+		vec.BufferizeString(u.RawQuery)
+	}
+	vec.fromTail(root, vector.TypeString, offsetQueryOrigin, lenQueryOrigin, offset)
+	offset = vec.BufLen()
+	if len(u.Fragment) > 0 {
+		vec.Bufferize(bHash)
+		vec.BufferizeString(u.EscapedFragment())
+	}
+	vec.fromTail(root, vector.TypeString, offsetHash, lenHash, offset)
+
+	vec.fromTail(root, vector.TypeObject, offsetQuery, lenQuery, vec.BufLen())
+	vec.fromTail(root, vector.TypeObject, offsetFragment, lenFragment, vec.BufLen())
+	opaque, io := vec.AcquireChildWithType(root, 1, vector.TypeObject)
+	vec.ReleaseNode(io, opaque)
+	vec.fromTail(root, vector.TypeArray, offsetHosts, lenHosts, vec.BufLen())
+
+	vec.ReleaseNode(i, root)
+	return nil
+}
+
+// Register child of root with given key and value copied to the buffer.
+func (vec *Vector) fromString(root *vector.Node, typ vector.Type, koff, klen int, value string) *vector.Node {
+	offset := vec.BufLen()
+	vec.BufferizeString(value)
+	return vec.fromTail(root, typ, koff, klen, offset)
+}
+
+// Register child of root with given key and value pointing to the tail of the buffer starting from offset.
+func (vec *Vector) fromTail(root *vector.Node, typ vector.Type, koff, klen, offset int) *vector.Node {
+	node, i := vec.AcquireChildWithType(root, 1, typ)
+	node.Key().Init(bKeys, koff, klen)
+	vec.setTail(node, offset)
+	vec.ReleaseNode(i, node)
+	return vec.GetByIdx(i)
+}
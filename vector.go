@@ -1,6 +1,9 @@
 package urlvector
 
 import (
+	"bytes"
+	"io"
+
 	"github.com/koykov/byteconv"
 	"github.com/koykov/vector"
 )
@@ -19,6 +22,9 @@ const (
 	idxQueryOrigin = 10
 	idxHash        = 11
 	idxQuery       = 12
+	idxFragment    = 13
+	idxOpaque      = 14
+	idxHosts       = 15
 
 	// Vector level flags.
 	flagCopy        = 8
@@ -26,24 +32,100 @@ const (
 	flagQueryParsed = 10
 	flagQuerySorted = 11
 	flagQueryMod    = 12
+	flagFragParsed  = 13
+	flagOpaque      = 14
+	flagOpqParsed   = 15
+	flagFile        = 16
+	flagHostsParsed = 17
+	flagSCP         = 18
 	// Byteptr level flags.
 	flagEscape = 8
 	flagBufSrc = 9
 )
 
+// Option represents bitmask of parse options.
+type Option uint
+
+const (
+	// OptionDSN enables parsing of comma separated hosts list in authority part, see Hosts().
+	OptionDSN Option = 1 << iota
+	// OptionSCP enables parsing of scp-like git remotes (git@github.com:org/repo.git), see ToSSH() and ToHTTPS().
+	OptionSCP
+	// OptionStrict enables strict validation, parsing fails with ErrBadPort if port isn't a number in range 0-65535.
+	OptionStrict
+)
+
 // Vector represents URL parser.
 type Vector struct {
 	vector.Vector
+	opts Option
+	// Temporary buffer to assemble URL from separate parts before parsing.
+	tmp []byte
+	// Modification counter, increments by every change of nodes or options.
+	mod uint
+	// Last URL assembled in the buffer by Bytes().
+	asm assembled
+	// Last unescaped path in the buffer, see PathBytes().
+	upath unescaped
+	// Scratch buffer of WriteTo().
+	out []byte
+	// Compaction threshold, buffer length after last compaction and spans scratch, see Compact().
+	cth, clen int
+	spans     []span
+}
+
+// Region of the buffer with assembled URL and vector state at the moment of assembling.
+type assembled struct {
+	off, len     int
+	nodes, query int
+	mod          uint
+	esc, ok      bool
+}
+
+// Region of the buffer with unescaped value and modification counter at the moment of unescaping.
+type unescaped struct {
+	off, len int
+	mod      uint
+	ok       bool
 }
 
 // NewVector makes new parser.
 func NewVector() *Vector {
 	vec := &Vector{}
 	vec.SetBit(vector.FlagInit, true)
-	vec.Helper = helper
+	vec.Helper = Helper{vec: vec}
 	return vec
 }
 
+// Initialize zero vector (e.g. struct field) to use without NewVector().
+func (vec *Vector) ensureInit() {
+	if !vec.CheckBit(vector.FlagInit) {
+		vec.SetBit(vector.FlagInit, true)
+		vec.Helper = Helper{vec: vec}
+	}
+}
+
+// SetOptions sets parse options. Options take effect on the next parsing and drops by Reset().
+func (vec *Vector) SetOptions(opts Option) *Vector {
+	vec.opts = opts
+	vec.touch()
+	return vec
+}
+
+// Options returns current parse options.
+func (vec *Vector) Options() Option {
+	return vec.opts
+}
+
+// Reset drops vector data and parse options.
+func (vec *Vector) Reset() {
+	vec.Vector.Reset()
+	vec.opts = 0
+	vec.asm.ok = false
+	vec.upath.ok = false
+	vec.cth, vec.clen = 0, 0
+}
+
 // Parse source bytes.
 func (vec *Vector) Parse(s []byte) error {
 	return vec.parse(s, false)
@@ -65,6 +147,9 @@ func (vec *Vector) ParseCopyString(s string) error {
 }
 
 // Bytes reassembles the vector into a valid URL bytes array.
+//
+// URL assembles in the vector buffer, repeated calls reuse it until the vector changes. See AppendBytes() to use own
+// buffer.
 func (vec *Vector) Bytes() []byte {
 	return vec.bytes(false)
 }
@@ -88,82 +173,70 @@ func (vec *Vector) StringEscaped() string {
 	return byteconv.B2S(vec.BytesEscaped())
 }
 
-// Internal marshaller.
-func (vec *Vector) bytes(esc bool) []byte {
-	// Bytes uses internal buffer as destination array to assemble the URL. So we need to save current length of the
-	// buffer and use it further as offset.
-	offset := vec.BufLen()
+// AppendBytes appends assembled URL to dst and returns the extended buffer.
+//
+// Unlike Bytes() doesn't use vector buffer.
+func (vec *Vector) AppendBytes(dst []byte) []byte {
+	return vec.appendBytes(dst, false)
+}
 
-	if scheme := vec.SchemeBytes(); len(scheme) > 0 {
-		vec.Bufferize(scheme)
-		vec.Bufferize(bSchemaSep)
-	} else if vec.Slashes() {
-		vec.Bufferize(bSlashes)
-	}
+// AppendBytesEscaped appends escaped URL to dst and returns the extended buffer.
+func (vec *Vector) AppendBytesEscaped(dst []byte) []byte {
+	return vec.appendBytes(dst, true)
+}
 
-	if username := vec.UsernameBytes(); len(username) > 0 {
-		vec.Bufferize(username)
-		if password := vec.PasswordBytes(); len(password) > 0 {
-			vec.Bufferize(bColon)
-			vec.Bufferize(password)
-		}
-		vec.Bufferize(bAt)
-	}
+// WriteTo writes assembled URL to w. Implements io.WriterTo interface.
+func (vec *Vector) WriteTo(w io.Writer) (int64, error) {
+	return vec.writeTo(w, false)
+}
 
-	if hostname := vec.HostnameBytes(); len(hostname) > 0 {
-		vec.Bufferize(hostname)
-		if port := vec.getByIdx(idxPort); port.Value().Len() > 0 {
-			vec.Bufferize(bColon)
-			vec.Bufferize(port.Bytes())
-		}
-	}
+// WriteEscapedTo writes escaped URL to w.
+func (vec *Vector) WriteEscapedTo(w io.Writer) (int64, error) {
+	return vec.writeTo(w, true)
+}
 
-	if path := vec.PathBytes(); len(path) > 0 {
-		if path[0] != '/' {
-			vec.Bufferize(bSlash)
-		}
-		if esc {
-			vecEscape(vec, path, modePath)
-		} else {
-			vec.Bufferize(path)
-		}
-	}
+func (vec *Vector) writeTo(w io.Writer, esc bool) (int64, error) {
+	vec.out = vec.appendBytes(vec.out[:0], esc)
+	n, err := w.Write(vec.out)
+	return int64(n), err
+}
 
-	if !vec.CheckBit(flagQueryParsed) {
-		if query := vec.QueryBytes(); len(query) > 0 {
-			if query[0] != '?' {
-				vec.Bufferize(bQM)
-			}
-			vec.Bufferize(query)
-		}
-	} else {
-		if query := vec.getByIdx(idxQuery); query.Limit() > 0 {
-			vec.Bufferize(bQM)
-			query.Each(func(idx int, node *vector.Node) {
-				if idx > 0 {
-					vec.Bufferize(bAmp)
-				}
-				vec.Bufferize(node.KeyBytes())
-				vec.Bufferize(bEq)
-				vecEscape(vec, node.Value().Bytes(), modeQuery)
-			})
-		}
+// Internal marshaller.
+func (vec *Vector) bytes(esc bool) []byte {
+	if vec.Len() == 0 {
+		// Nothing parsed yet.
+		return nil
 	}
-
-	if hash := vec.HashBytes(); len(hash) > 0 {
-		if hash[0] != '#' {
-			vec.Bufferize(bHash)
-		}
-		if esc {
-			vecEscape(vec, hash, modeHash)
-		} else {
-			vec.Bufferize(hash)
-		}
+	// Bytes uses internal buffer as destination array to assemble the URL. Previously assembled URL reuses if nothing
+	// changed since, otherwise URL appends to the buffer.
+	if a := &vec.asm; a.ok && a.esc == esc && a.mod == vec.mod && a.off+a.len == vec.BufLen() &&
+		a.nodes == vec.Len() && a.query == vec.GetByIdx(idxQuery).Limit() && !vec.CheckBit(flagQueryMod) {
+		return vec.Buf()[a.off:]
+	}
+	offset := vec.BufLen()
+	vec.BufReplaceWith(vec.appendBytes(vec.Buf(), esc))
+	vec.asm = assembled{
+		off:   offset,
+		len:   vec.BufLen() - offset,
+		nodes: vec.Len(),
+		query: vec.GetByIdx(idxQuery).Limit(),
+		mod:   vec.mod,
+		esc:   esc,
+		ok:    true,
 	}
-
 	return vec.Buf()[offset:]
 }
 
+// Mark vector as modified, so URL assembled by Bytes() becomes outdated.
+func (vec *Vector) touch() {
+	vec.mod++
+}
+
+// Append assembled URL to dst. Vector buffer keeps untouched.
+func (vec *Vector) appendBytes(dst []byte, esc bool) []byte {
+	return vec.appendComponents(dst, ComponentAll, esc, nil)
+}
+
 // Scheme returns scheme node.
 func (vec *Vector) Scheme() *vector.Node {
 	return vec.getByIdx(idxScheme)
@@ -199,17 +272,30 @@ func (vec *Vector) Hostname() *vector.Node {
 	return vec.getByIdx(idxHostname)
 }
 
-// Port returns port as integer.
+// Port returns port as integer. Returns 0 if port is absent or invalid, see PortValue().
 func (vec *Vector) Port() int {
-	i, _ := vec.getByIdx(idxPort).Int()
-	return int(i)
+	port, _ := vec.PortValue()
+	return int(port)
 }
 
-// Path returns path node.
+// Path returns path node. Node keeps the path escaped (see Value().RawBytes()), but Bytes() and String() return
+// unescaped version.
 func (vec *Vector) Path() *vector.Node {
 	return vec.getByIdx(idxPath)
 }
 
+// Unescape path to the buffer. Unescaped path reuses until the next modification of the vector.
+func (vec *Vector) unescapePath(p *vector.Byteptr) []byte {
+	if u := vec.upath; u.ok && u.mod == vec.mod {
+		return vec.Buf()[u.off : u.off+u.len]
+	}
+	offset := vec.BufLen()
+	vec.BufReplaceWith(bufUnescape(vec.Buf(), p.RawBytes(), modePath))
+	vec.SetBit(flagBufMod, true)
+	vec.upath = unescaped{off: offset, len: vec.BufLen() - offset, mod: vec.mod, ok: true}
+	return vec.Buf()[offset:]
+}
+
 // Query returns query node with origin query params order.
 func (vec *Vector) Query() *vector.Node {
 	query := vec.getByIdx(idxQuery)
@@ -217,6 +303,7 @@ func (vec *Vector) Query() *vector.Node {
 		vec.SetBit(flagQueryParsed, true)
 		vec.parseQueryParams(query)
 	}
+	vec.rebase(query)
 	return query
 }
 
@@ -228,6 +315,7 @@ func (vec *Vector) QuerySort() *Vector {
 		children := query.ChildrenIndices()
 		quickSort1(vec, children, 0, len(children)-1)
 		vec.SetBit(flagQueryMod, true)
+		vec.touch()
 	}
 	return vec
 }
@@ -239,33 +327,85 @@ func (vec *Vector) queryOrigin() *vector.Node {
 	if vec.CheckBit(flagQueryMod) {
 		vec.SetBit(flagQueryMod, false)
 		offset := vec.BufLen()
-		limit := 1
-		vec.BufferizeString("?")
-		query := vec.getByIdx(idxQuery)
-		query.Each(func(idx int, node *vector.Node) {
-			if idx > 0 {
-				vec.BufferizeString("&")
-				limit++
-			}
-			key := vecEscape(vec, node.KeyBytes(), modeQuery)
-			vec.BufferizeString("=")
-			val := vecEscape(vec, node.Bytes(), modeQuery)
-			limit += len(key) + len(val) + 1
-		})
+		vec.bufQuery(vec.getByIdx(idxQuery))
 		vec.SetBit(flagBufMod, true)
 
-		queryOrigin.Value().Init(vec.Buf(), offset, limit)
+		queryOrigin.Value().Init(vec.Buf(), offset, vec.BufLen()-offset)
 		queryOrigin.Value().SetBit(flagBufSrc, true)
 		vec.ReleaseNode(queryOrigin.Index(), queryOrigin)
 	}
 	return queryOrigin
 }
 
+// Write parsed query params to the buffer.
+func (vec *Vector) bufQuery(query *vector.Node) {
+	vec.BufReplaceWith(appendQuery(vec.Buf(), query, nil))
+}
+
+// Append parsed query params to dst. Array params writes as repeated keys, sensitive values masks if policy given.
+func appendQuery(dst []byte, query *vector.Node, rp *RedactPolicy) []byte {
+	offset := len(dst)
+	dst = append(dst, bQM...)
+	query.Each(func(_ int, node *vector.Node) {
+		key := node.KeyBytes()
+		if rp.sensitive(key) {
+			n := 1
+			if node.Type() == vector.TypeArray {
+				n = node.Limit()
+			}
+			for i := 0; i < n; i++ {
+				dst = appendQueryParam(dst, offset, key, nil)
+				dst = rp.appendMask(dst)
+			}
+			return
+		}
+		if node.Type() == vector.TypeArray {
+			node.Each(func(_ int, item *vector.Node) {
+				dst = appendQueryParam(dst, offset, key, item.Bytes())
+			})
+			return
+		}
+		dst = appendQueryParam(dst, offset, key, node.Bytes())
+	})
+	return dst
+}
+
+// Append key=value pair of query assembling from offset. Key writes raw since parser doesn't unescape keys.
+func appendQueryParam(dst []byte, offset int, key, val []byte) []byte {
+	if len(dst) > offset+1 {
+		dst = append(dst, bAmp...)
+	}
+	dst = append(dst, key...)
+	dst = append(dst, bEq...)
+	return bufEscape(dst, val, modeQuery)
+}
+
 // Hash returns hash node.
 func (vec *Vector) Hash() *vector.Node {
 	return vec.getByIdx(idxHash)
 }
 
+// Internal opaque path getter.
+//
+// Opaque path will be parsed according scheme by first attempt to access it.
+func (vec *Vector) opaque() *vector.Node {
+	opaque := vec.getByIdx(idxOpaque)
+	if !vec.CheckBit(flagOpqParsed) {
+		vec.SetBit(flagOpqParsed, true)
+		if vec.CheckBit(flagOpaque) {
+			switch scheme := vec.SchemeBytes(); {
+			case bytes.EqualFold(scheme, bData):
+				vec.parseData(opaque)
+			case bytes.EqualFold(scheme, bMailto):
+				vec.parseMailto(opaque)
+			}
+		}
+		opaque = vec.getByIdx(idxOpaque)
+	}
+	vec.rebase(opaque)
+	return opaque
+}
+
 // Get node by index considering flags.
 func (vec *Vector) getByIdx(idx int) *vector.Node {
 	node := vec.GetByIdx(idx)
@@ -274,3 +414,48 @@ func (vec *Vector) getByIdx(idx int) *vector.Node {
 	}
 	return node
 }
+
+// Get node by index for low-level processing of all nodes. Buffer sourced keys and values re-addresses to the buffer.
+func (vec *Vector) nodeAt(idx int) *vector.Node {
+	node := vec.GetByIdx(idx)
+	vec.takeAddr(node)
+	return node
+}
+
+// Re-address buffer sourced children of node (recursively) to the buffer.
+//
+// Lazy parsed nodes (query params, fragment, ...) may point to the buffer, so they must be re-addressed by access since
+// buffer may be reallocated after parsing.
+func (vec *Vector) rebase(node *vector.Node) {
+	if !vec.CheckBit(flagBufMod) || node.Limit() == 0 {
+		return
+	}
+	children := node.ChildrenIndices()
+	for i := 0; i < len(children); i++ {
+		child := vec.GetByIdx(children[i])
+		vec.takeAddr(child)
+		if t := child.Type(); t == vector.TypeObject || t == vector.TypeArray {
+			vec.rebase(child)
+		}
+	}
+}
+
+// Re-address buffer sourced key and value of node to the buffer.
+func (vec *Vector) takeAddr(node *vector.Node) {
+	if !vec.CheckBit(flagBufMod) {
+		return
+	}
+	if node.Key().CheckBit(flagBufSrc) {
+		node.Key().TakeAddr(vec.Buf())
+	}
+	if node.Value().CheckBit(flagBufSrc) {
+		node.Value().TakeAddr(vec.Buf())
+	}
+}
+
+// Check if raw value is exactly prefix followed by colon with empty tail (user: or host:).
+//
+// Prefix compares by bytes, so raw must contain it as is (raw username of auth, raw hostname of host).
+func hasEmptyTail(raw, prefix []byte) bool {
+	return len(raw) == len(prefix)+1 && raw[len(prefix)] == ':' && bytes.Equal(raw[:len(prefix)], prefix)
+}
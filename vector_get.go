@@ -60,12 +60,14 @@ func (vec *Vector) HostnameString() string {
 	return vec.Hostname().String()
 }
 
-// PathBytes returns password as bytes.
+// PathBytes returns unescaped path as bytes.
+//
+// Path node keeps the path escaped, so unescaped version assembles in internal buffer once per vector modification.
 func (vec *Vector) PathBytes() []byte {
 	return vec.Path().Bytes()
 }
 
-// PathString returns password as string.
+// PathString returns unescaped path as string.
 func (vec *Vector) PathString() string {
 	return vec.Path().String()
 }
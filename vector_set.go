@@ -1,6 +1,7 @@
 package urlvector
 
 import (
+	"github.com/koykov/bytealg"
 	"github.com/koykov/byteconv"
 	"github.com/koykov/vector"
 )
@@ -45,9 +46,12 @@ func (vec *Vector) SetPasswordString(password string) *Vector {
 	return vec.SetPasswordBytes(byteconv.S2B(password))
 }
 
-// SetHostBytes replaces host with bytes.
+// SetHostBytes replaces host with bytes. Hostname and port updates as well, they takes from the first host of multiple
+// hosts URL (see OptionDSN).
 func (vec *Vector) SetHostBytes(host []byte) *Vector {
-	return vec.set(vec.Host(), host)
+	vec.resetHosts()
+	vec.set(vec.Host(), host)
+	return vec.syncHostname()
 }
 
 // SetHostString replaces host with string.
@@ -55,9 +59,12 @@ func (vec *Vector) SetHostString(host string) *Vector {
 	return vec.SetHostBytes(byteconv.S2B(host))
 }
 
-// SetHostnameBytes replaces hostname with bytes.
+// SetHostnameBytes replaces hostname with bytes. Host updates as well, so hostname replaces all hosts of multiple hosts
+// URL (see OptionDSN).
 func (vec *Vector) SetHostnameBytes(hostname []byte) *Vector {
-	return vec.set(vec.Hostname(), hostname)
+	vec.resetHosts()
+	vec.set(vec.Hostname(), hostname)
+	return vec.syncHost()
 }
 
 // SetHostnameString replaces hostname with string.
@@ -65,8 +72,14 @@ func (vec *Vector) SetHostnameString(hostname string) *Vector {
 	return vec.SetHostnameBytes(byteconv.S2B(hostname))
 }
 
-// SetPort replaces port.
+// SetPort replaces port. Out of range values (less than 0 or greater than 65535) ignores, vector keeps untouched.
+// Host updates as well, so port replaces all hosts of multiple hosts URL (see OptionDSN) with the first one.
 func (vec *Vector) SetPort(port int) *Vector {
+	if port < 0 || port > maxPort {
+		return vec
+	}
+	vec.resetHosts()
+	vec.touch()
 	vec.SetBit(flagBufMod, true)
 	offset := vec.BufLen()
 	vec.BufferizeInt(int64(port))
@@ -74,12 +87,15 @@ func (vec *Vector) SetPort(port int) *Vector {
 	node := vec.getByIdx(idxPort)
 	node.Value().Init(vec.Buf(), offset, l)
 	node.Value().SetBit(flagBufSrc, true)
-	return vec
+	return vec.syncHost()
 }
 
 // SetPathBytes replaces path with bytes.
 func (vec *Vector) SetPathBytes(path []byte) *Vector {
-	return vec.set(vec.Path(), path)
+	vec.resetOpaque()
+	vec.set(vec.Path(), path)
+	vec.Path().Value().SetBit(flagEscape, bytealg.IndexByteAtBytes(path, '%', 0) >= 0)
+	return vec
 }
 
 // SetPathString replaces path with string.
@@ -89,11 +105,7 @@ func (vec *Vector) SetPathString(path string) *Vector {
 
 // SetQueryBytes replaces query with bytes.
 func (vec *Vector) SetQueryBytes(query []byte) *Vector {
-	vec.SetBit(flagQueryParsed, false)
-	vec.ForgetFrom(idxQuery + 1)
-	node := vec.GetByIdx(idxQuery)
-	vec.Index.Reset(node.Depth(), node.Offset())
-	node.SetLimit(0)
+	vec.resetQuery()
 	return vec.set(vec.queryOrigin(), query)
 }
 
@@ -104,6 +116,7 @@ func (vec *Vector) SetQueryString(query string) *Vector {
 
 // SetHashBytes replaces hash with bytes.
 func (vec *Vector) SetHashBytes(hash []byte) *Vector {
+	vec.resetFragment()
 	return vec.set(vec.Hash(), hash)
 }
 
@@ -112,12 +125,83 @@ func (vec *Vector) SetHashString(hash string) *Vector {
 	return vec.SetHashBytes(byteconv.S2B(hash))
 }
 
+// Drop parsed query params together with all nodes parsed by demand.
+func (vec *Vector) resetQuery() {
+	vec.touch()
+	vec.SetBit(flagQueryParsed, false)
+	vec.resetFragment()
+	vec.resetOpaque()
+	vec.resetHosts()
+	vec.ForgetFrom(idxHosts + 1)
+	node := vec.GetByIdx(idxQuery)
+	// All nodes parsed by demand are forgotten, so drop their index rows (root children row keeps).
+	for depth := node.Depth() + 1; vec.Index.Len(depth) > 0; depth++ {
+		vec.Index.Reset(depth, 0)
+	}
+	node.SetLimit(0)
+}
+
+// Drop parsed fragment to parse it again by next access.
+func (vec *Vector) resetFragment() {
+	vec.touch()
+	vec.SetBit(flagFragParsed, false)
+	vec.GetByIdx(idxFragment).SetLimit(0)
+}
+
+// Drop parsed opaque path to parse it again by next access.
+func (vec *Vector) resetOpaque() {
+	vec.touch()
+	vec.SetBit(flagOpqParsed, false)
+	vec.GetByIdx(idxOpaque).SetLimit(0)
+}
+
+// Assemble host from hostname and port.
+func (vec *Vector) syncHost() *Vector {
+	offset := vec.BufLen()
+	vec.Bufferize(vec.HostnameBytes())
+	if port := vec.getByIdx(idxPort).Bytes(); len(port) > 0 {
+		vec.Bufferize(bColon)
+		vec.Bufferize(port)
+	}
+	return vec.setTail(vec.Host(), offset)
+}
+
+// Point hostname and port to the first host.
+func (vec *Vector) syncHostname() *Vector {
+	host := vec.Host().Value()
+	raw, offset := host.RawBytes(), host.Offset()
+	hi := len(raw)
+	if vec.opts&OptionDSN != 0 {
+		if i := bytealg.IndexByteAtBytes(raw, ',', 0); i >= 0 {
+			hi = i
+		}
+	}
+	hostname, port := vec.Hostname().Value(), vec.getByIdx(idxPort).Value()
+	if i := portColIdx(raw, 0, hi); i >= 0 {
+		hostname.Init(vec.Buf(), offset, i)
+		port.Init(vec.Buf(), offset+i+1, hi-i-1)
+	} else {
+		hostname.Init(vec.Buf(), offset, hi)
+		port.Init(vec.Buf(), offset+hi, 0)
+	}
+	hostname.SetBit(flagBufSrc, true)
+	port.SetBit(flagBufSrc, true)
+	return vec
+}
+
 // Internal setter.
 func (vec *Vector) set(node *vector.Node, s []byte) *Vector {
-	vec.SetBit(flagBufMod, true)
 	offset := vec.BufLen()
 	vec.Bufferize(s)
-	node.Value().Init(vec.Buf(), offset, len(s))
+	return vec.setTail(node, offset)
+}
+
+// Point node to the tail of the buffer starting from offset.
+func (vec *Vector) setTail(node *vector.Node, offset int) *Vector {
+	vec.touch()
+	vec.SetBit(flagBufMod, true)
+	node.Value().Init(vec.Buf(), offset, vec.BufLen()-offset)
 	node.Value().SetBit(flagBufSrc, true)
+	vec.checkCompact()
 	return vec
 }